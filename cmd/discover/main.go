@@ -0,0 +1,89 @@
+// Command discover finds the token parameters that reproduce codes copied
+// from a bank app.
+//
+//	discover -seed 9D5D96988C8D267DD4FD4B5AF7EFB394A493B85A 1652703737:778024
+//
+// Every observation is a time and the code the app showed at that time. The
+// time is a unix timestamp or an RFC 3339 date.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"otp/internal/token"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	seed := flag.String("seed", "", "hex encoded seed of the token")
+	maxOffset := flag.Duration("max-offset", 5*time.Minute, "largest clock offset of the app to try")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -seed <hex> <time>:<code>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if len(*seed) == 0 || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var observations []token.Observation
+	for _, arg := range flag.Args() {
+		observation, err := parseObservation(arg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		observations = append(observations, observation)
+	}
+
+	matches, err := token.Discover(strings.ToUpper(*seed), observations, token.DiscoverOptions{MaxOffset: *maxOffset})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(matches) == 0 {
+		log.Fatal("no parameters reproduce the codes")
+	}
+
+	if len(matches) > 1 {
+		fmt.Printf("%d parameter sets reproduce the codes, more observations can tell them apart\n\n", len(matches))
+	}
+
+	for _, match := range matches {
+		config, err := json.MarshalIndent(match.Token, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("clock offset : %v\n%s\n\n", match.Offset, config)
+	}
+}
+
+func parseObservation(arg string) (token.Observation, error) {
+	i := strings.LastIndex(arg, ":")
+	if i < 0 {
+		return token.Observation{}, fmt.Errorf("observation %q is not in <time>:<code> format", arg)
+	}
+
+	value, code := arg[:i], arg[i+1:]
+	if _, err := strconv.ParseUint(code, 10, 64); err != nil {
+		return token.Observation{}, fmt.Errorf("code %q is not a number", code)
+	}
+
+	if timestamp, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return token.Observation{Time: time.Unix(timestamp, 0), Code: code}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return token.Observation{}, fmt.Errorf("time %q is neither a unix timestamp nor an RFC 3339 date", value)
+	}
+
+	return token.Observation{Time: t, Code: code}, nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"otp/internal/activation"
+	"otp/internal/provider"
+	"otp/internal/token"
+	"otp/pkg/qr"
+)
+
+var activateCommand = &command{
+	name:        "activate",
+	usage:       "[flags] [bank]",
+	description: "Activate a token of a bank and save it",
+	run:         runActivate,
+}
+
+func runActivate(a *app, args []string) error {
+	flags := a.flags()
+	qrFile := flags.String("qr", "", "image of the activation QR code, a screenshot or photo")
+	payload := flags.String("payload", "", "content of the activation QR code, its json or an otpauth url")
+	name := flags.String("name", "", "name of the token in the store (default bank and account)")
+	verificationCode := flags.String("verification-code", "", "verification code sent by sms")
+	pin := flags.String("pin", "", "pin to protect the token")
+	protectPin := flags.Bool("protect-pin", false, "keep only the server secret, so the pin is needed for codes")
+	pinVerifier := flags.Bool("pin-verifier", false, "with -protect-pin, keep a hash of the pin to detect wrong pins")
+	replace := flags.Bool("replace", false, "replace a token with the same name")
+	list := flags.Bool("list", false, "list the banks that can be activated")
+
+	args, err := a.parse(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		banks := []bankOutput{}
+		for _, p := range provider.Providers() {
+			banks = append(banks, bankOutput{ID: p.ID(), Name: p.Name()})
+			if !a.json() {
+				fmt.Fprintf(a.stdout, "%-14s %s\n", p.ID(), p.Name())
+			}
+		}
+
+		if a.json() {
+			return a.writeJSON(struct {
+				Banks []bankOutput `json:"banks"`
+			}{Banks: banks})
+		}
+		return nil
+	}
+
+	if len(args) > 1 {
+		return usagef("too many arguments")
+	}
+	var bank string
+	if len(args) == 1 {
+		bank = args[0]
+	}
+
+	if len(*qrFile) > 0 && len(*payload) > 0 {
+		return usagef("-qr and -payload can not be used together")
+	}
+	if len(bank) == 0 && len(*qrFile) == 0 && len(*payload) == 0 {
+		return usagef("a bank or an activation QR code is required")
+	}
+
+	s, err := a.openStore()
+	if err != nil {
+		return err
+	}
+
+	request := activation.Request{
+		Bank:             bank,
+		VerificationCode: *verificationCode,
+		Pin:              *pin,
+		Prompt:           a.promptField,
+		Options: provider.Options{
+			ProtectPin:       *protectPin,
+			StorePinVerifier: *pinVerifier,
+		},
+	}
+
+	var tokens []*token.Token
+	if len(*qrFile) == 0 && len(*payload) == 0 {
+		t, err := activation.ActivateBank(bank, request)
+		if err != nil {
+			return err
+		}
+		tokens = append(tokens, t)
+	} else {
+		payloads, err := a.payloads(*qrFile, *payload)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range payloads {
+			activated, err := a.activatePayload(p, request)
+			if err != nil {
+				return err
+			}
+			tokens = append(tokens, activated...)
+		}
+	}
+
+	if len(*name) > 0 && len(tokens) > 1 {
+		return fmt.Errorf("-name can not name %d tokens", len(tokens))
+	}
+
+	var activated []activatedOutput
+	for _, t := range tokens {
+		tokenName := *name
+		if len(tokenName) == 0 {
+			tokenName = s.NameFor(t)
+		}
+
+		if err := s.Add(tokenName, t, *replace); err != nil {
+			return err
+		}
+		activated = append(activated, activatedOutput{Name: tokenName, Token: t})
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	if a.json() {
+		return a.writeJSON(struct {
+			Tokens []activatedOutput `json:"tokens"`
+		}{Tokens: activated})
+	}
+
+	for _, t := range activated {
+		fmt.Fprintf(a.stdout, "Saved %s\n", t.Name)
+	}
+	return nil
+}
+
+type bankOutput struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// activatedOutput is an activated token as it is saved in the store.
+type activatedOutput struct {
+	Name  string       `json:"name"`
+	Token *token.Token `json:"token"`
+}
+
+// payloads returns the payloads of an image or of the -payload flag.
+func (a *app) payloads(qrFile, payload string) ([]*activation.Payload, error) {
+	if len(payload) > 0 {
+		p, err := activation.ParsePayload(payload)
+		if err != nil {
+			return nil, err
+		}
+		return []*activation.Payload{p}, nil
+	}
+
+	img, err := qr.ReadImageFile(qrFile)
+	if err != nil {
+		return nil, err
+	}
+	return activation.FromImage(img)
+}
+
+// activatePayload asks for the verification code and pin of an activation
+// code that are not given, so their lengths can be checked before the bank
+// is called.
+func (a *app) activatePayload(payload *activation.Payload, request activation.Request) ([]*token.Token, error) {
+	if payload.QrData == nil {
+		return activation.ActivatePayload(payload, request)
+	}
+
+	var err error
+	if len(request.VerificationCode) == 0 {
+		request.VerificationCode, err = a.prompt(fmt.Sprintf("Verification code sent by sms (%d digits)", payload.QrData.VerificationCodeLength))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(request.Pin) == 0 {
+		request.Pin, err = a.prompt(fmt.Sprintf("Arbitrary pin to protect the token (%d digits)", payload.QrData.PinLength))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return activation.ActivatePayload(payload, request)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+)
+
+var codeCommand = &command{
+	name:        "code",
+	usage:       "[flags] <name>",
+	description: "Print the current codes of a token",
+	run:         runCode,
+}
+
+func runCode(a *app, args []string) error {
+	flags := a.flags()
+	slot := flags.String("slot", "", "slot of the code, like pin1, pin2 or modern (default every slot)")
+	pin := flags.String("pin", "", "pin of a protected token (default $"+pinEnv+" or asked)")
+	offset := offsetFlag(flags)
+
+	args, err := a.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return usagef("a token name is required")
+	}
+
+	s, err := a.openStore()
+	if err != nil {
+		return err
+	}
+
+	t, err := s.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	names, err := slots(t, *slot)
+	if err != nil {
+		return err
+	}
+
+	unlocked, err := a.unlock(t, *pin)
+	if err != nil {
+		return err
+	}
+
+	unlocked = withOffset(unlocked, *offset)
+	now := unlocked.Now()
+	var codes []codeOutput
+	for _, name := range names {
+		code, err := unlocked.CodeAt(name, now)
+		if err != nil {
+			return fmt.Errorf("%s : %w", name, err)
+		}
+
+		switch {
+		case a.json():
+			codes = append(codes, newCodeOutput(args[0], name, t, code, now))
+		case len(names) == 1:
+			// A single code is printed alone, so scripts can use it
+			fmt.Fprintln(a.stdout, code.Value)
+		case t.IsHotp():
+			fmt.Fprintf(a.stdout, "%-7s %s\n", name, code.Value)
+		default:
+			fmt.Fprintf(a.stdout, "%-7s %s  %ds\n", name, code.Value, remainingSeconds(code, now))
+		}
+	}
+
+	// Every code of a counter based token is used once
+	if t.IsHotp() {
+		t.Counter++
+		if err := s.Save(); err != nil {
+			return err
+		}
+	}
+
+	if a.json() {
+		return a.writeJSON(struct {
+			Codes []codeOutput `json:"codes"`
+		}{Codes: codes})
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"otp/internal/token"
+	"strings"
+	"text/tabwriter"
+)
+
+var listCommand = &command{
+	name:        "list",
+	usage:       "",
+	description: "List the saved tokens",
+	run:         runList,
+}
+
+var removeCommand = &command{
+	name:        "remove",
+	usage:       "<name>...",
+	description: "Remove saved tokens",
+	run:         runRemove,
+}
+
+func runList(a *app, args []string) error {
+	args, err := a.parse(a.flags(), args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return usagef("list takes no arguments")
+	}
+
+	s, err := a.openStore()
+	if err != nil {
+		return err
+	}
+
+	if a.json() {
+		output := struct {
+			Tokens []tokenOutput `json:"tokens"`
+		}{Tokens: []tokenOutput{}}
+
+		for _, name := range s.Names() {
+			t, err := s.Get(name)
+			if err != nil {
+				return err
+			}
+			output.Tokens = append(output.Tokens, newTokenOutput(name, t))
+		}
+		return a.writeJSON(output)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tBANK\tACCOUNT\tTYPE\tSLOTS\tPIN")
+	for _, name := range s.Names() {
+		t, err := s.Get(name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", name, t.BankName, t.AccountId, tokenType(t), slotList(t), pinState(t))
+	}
+	return w.Flush()
+}
+
+func runRemove(a *app, args []string) error {
+	args, err := a.parse(a.flags(), args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return usagef("a token name is required")
+	}
+
+	s, err := a.openStore()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range args {
+		if err := s.Remove(name); err != nil {
+			return err
+		}
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	if a.json() {
+		return a.writeJSON(struct {
+			Removed []string `json:"removed"`
+		}{Removed: args})
+	}
+	return nil
+}
+
+func tokenType(t *token.Token) string {
+	if t.IsHotp() {
+		return token.TypeHotp
+	}
+	return token.TypeTotp
+}
+
+func slotList(t *token.Token) string {
+	var names []string
+	for _, slot := range t.SlotNames() {
+		names = append(names, string(slot))
+	}
+	return strings.Join(names, ",")
+}
+
+func pinState(t *token.Token) string {
+	if t.IsPinProtected() {
+		return "protected"
+	}
+	return "-"
+}
@@ -0,0 +1,378 @@
+// Command otp activates bank tokens, keeps them in a local store and
+// generates their codes.
+//
+//	otp activate -qr activation.png saman
+//	otp list
+//	otp code saman-966775
+//	otp watch -slot pin2
+//	otp sync saman-966775
+//	otp show -qr saman-966775
+//	otp import -format aegis backup.json
+//	otp export -format otpauth -file tokens.txt
+//	otp code -output json saman-966775
+//
+// Tokens are kept in tokens.json of the user configuration directory, or in
+// the file of the -store flag or the OTP_STORE environment variable.
+//
+// Tokens of banks follow the clock of the bank server. Its offset is
+// estimated during activation and kept in the store, and sync estimates it
+// again. The -offset flag of code and watch corrects the system clock
+// instead.
+//
+// With -output json, every command prints a json object instead of text,
+// and errors are printed as {"error": {"code": ..., "message": ...}} to the
+// standard output. The watch command prints an object on a line whenever a
+// code changes.
+//
+// The exit code is 0 on success, 1 on errors and 2 on wrong usage.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"otp/internal/clock"
+	"otp/internal/provider"
+	"otp/internal/store"
+	"otp/internal/token"
+	"strings"
+	"time"
+
+	// Every bank that can activate a token
+	_ "otp/internal/provider/all"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// pinEnv is used for pin protected tokens when -pin is not given.
+const pinEnv = "OTP_PIN"
+
+// command is a subcommand of otp.
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(a *app, args []string) error
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		activateCommand,
+		listCommand,
+		codeCommand,
+		watchCommand,
+		syncCommand,
+		showCommand,
+		removeCommand,
+		importCommand,
+		exportCommand,
+	}
+}
+
+// usageError is returned by commands that are called wrongly.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// app holds what commands share.
+type app struct {
+	stdin     *bufio.Reader
+	stdout    io.Writer
+	stderr    io.Writer
+	storePath string
+	output    string
+	store     *store.Store
+	command   *command
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs otp with the arguments and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	a := &app{stdin: bufio.NewReader(stdin), stdout: stdout, stderr: stderr}
+
+	flags := flag.NewFlagSet("otp", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&a.storePath, "store", "", "token store file (default $"+store.PathEnv+" or the user configuration directory)")
+	flags.StringVar(&a.output, "output", outputText, "output format, text or json")
+	flags.Usage = func() {
+		a.printUsage(flags)
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		// The flag package has already printed the usage
+		return a.usage(nil, err.Error())
+	}
+
+	if flags.NArg() == 0 {
+		return a.usage(flags, "a command is required")
+	}
+
+	name, args := flags.Arg(0), flags.Args()[1:]
+	if name == "help" {
+		// Help that is asked for goes to standard output
+		flags.SetOutput(stdout)
+		a.stderr = stdout
+		if len(args) == 0 {
+			a.printUsage(flags)
+			return exitOK
+		}
+		name, args = args[0], []string{"-h"}
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			a.command = c
+			return a.exit(c.run(a, args))
+		}
+	}
+
+	return a.usage(flags, fmt.Sprintf("unknown command %q", name))
+}
+
+// usage reports wrong usage of otp itself and returns its exit code. The
+// usage of otp is printed if flags is set, unless the output is json.
+func (a *app) usage(flags *flag.FlagSet, message string) int {
+	if a.json() {
+		if err := a.writeJSON(errorOutput{Error: errorBody{Code: "usage", Message: message}}); err != nil {
+			fmt.Fprintf(a.stderr, "otp: %v\n", err)
+		}
+		return exitUsage
+	}
+
+	if flags != nil {
+		fmt.Fprintf(a.stderr, "otp: %s\n\n", message)
+		a.printUsage(flags)
+	}
+	return exitUsage
+}
+
+func (a *app) printUsage(flags *flag.FlagSet) {
+	w := a.stderr
+	fmt.Fprintf(w, "Usage: otp [-store file] [-output format] <command> [flags] [arguments]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(w, "\nRun otp help <command> for the flags of a command.\n\nFlags:\n")
+	flags.PrintDefaults()
+}
+
+// exit returns the exit code of the error of a command.
+func (a *app) exit(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+
+	if a.json() {
+		message := err.Error()
+		if len(message) == 0 {
+			message = "wrong flags, see otp help " + a.command.name
+		}
+		if err := a.writeJSON(errorOutput{Error: errorBody{Code: errorCode(err), Message: message}}); err != nil {
+			fmt.Fprintf(a.stderr, "otp %s: %v\n", a.command.name, err)
+		}
+	}
+
+	// Wrong flags are already reported by the flag package
+	var usage *usageError
+	if errors.As(err, &usage) {
+		if len(usage.message) > 0 && !a.json() {
+			fmt.Fprintf(a.stderr, "otp %s: %s\n", a.command.name, usage.message)
+			fmt.Fprintf(a.stderr, "Usage: otp %s %s\n", a.command.name, a.command.usage)
+		}
+		return exitUsage
+	}
+
+	if !a.json() {
+		fmt.Fprintf(a.stderr, "otp %s: %v\n", a.command.name, err)
+	}
+	return exitError
+}
+
+// flags returns the flag set of the current command.
+func (a *app) flags() *flag.FlagSet {
+	c := a.command
+	flags := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.StringVar(&a.output, "output", a.output, "output format, text or json")
+	flags.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: otp %s %s\n\n%s.\n", c.name, c.usage, c.description)
+		fmt.Fprintf(a.stderr, "\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parse parses flags that come before or after the arguments, as in
+// otp code -slot pin2 name and otp code name -slot pin2, and returns the
+// arguments.
+func (a *app) parse(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+			return nil, usagef("")
+		}
+
+		args = flags.Args()
+		if len(args) == 0 {
+			if a.output != outputText && a.output != outputJSON {
+				return nil, usagef("unknown output format %q, use text or json", a.output)
+			}
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// openStore opens the token store once.
+func (a *app) openStore() (*store.Store, error) {
+	if a.store != nil {
+		return a.store, nil
+	}
+
+	path := a.storePath
+	if len(path) == 0 {
+		var err error
+		path, err = store.DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	a.store = s
+	return s, nil
+}
+
+// prompt asks for a line of input.
+func (a *app) prompt(question string) (string, error) {
+	fmt.Fprintf(a.stderr, "%s: ", question)
+
+	line, err := a.stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		if err == io.EOF {
+			return "", fmt.Errorf("no input for %s", strings.ToLower(question))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// promptField asks for a value of an activation session.
+func (a *app) promptField(field provider.Field) (string, error) {
+	question := field.Description
+	if len(question) == 0 {
+		question = field.Name
+	}
+	if field.Optional {
+		question += " (optional)"
+	}
+	return a.prompt(question)
+}
+
+// unlock returns a token that generates codes. Pin protected tokens are
+// unlocked with the pin of the flag, of OTP_PIN or asked for.
+func (a *app) unlock(t *token.Token, pin string) (*token.Token, error) {
+	if !t.IsPinProtected() {
+		return t, nil
+	}
+
+	if len(pin) == 0 {
+		pin = os.Getenv(pinEnv)
+	}
+	if len(pin) == 0 {
+		var err error
+		pin, err = a.prompt("Pin of the token")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return t.Unlock(pin)
+}
+
+// offsetFlag adds the -offset flag of commands that generate codes.
+func offsetFlag(flags *flag.FlagSet) *time.Duration {
+	return flags.Duration("offset", 0, "correction of the system clock, like 90s or -2m (default the clock of the bank server)")
+}
+
+// withOffset returns a copy of the token that follows the system clock
+// corrected by offset, instead of the clock of its bank server. A zero
+// offset keeps the clock of the token.
+func withOffset(t *token.Token, offset time.Duration) *token.Token {
+	if offset == 0 {
+		return t
+	}
+
+	shifted := *t
+	shifted.Clock = clock.Offset(offset)
+	return &shifted
+}
+
+// slots returns the slot of the flag, or every distinct slot of the token.
+// Tokens of authenticator apps generate the same code in every slot.
+func slots(t *token.Token, slot string) ([]token.Slot, error) {
+	if len(slot) == 0 {
+		var names []token.Slot
+		var configs []token.SlotConfig
+		for _, name := range t.SlotNames() {
+			config, err := t.Slot(name)
+			if err != nil {
+				return nil, err
+			}
+			if !containsConfig(configs, config) {
+				names = append(names, name)
+				configs = append(configs, config)
+			}
+		}
+		return names, nil
+	}
+
+	for _, name := range t.SlotNames() {
+		if strings.EqualFold(string(name), slot) {
+			return []token.Slot{name}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w : %s", token.ErrUnknownSlot, slot)
+}
+
+func containsConfig(configs []token.SlotConfig, config token.SlotConfig) bool {
+	for _, c := range configs {
+		if c == config {
+			return true
+		}
+	}
+	return false
+}
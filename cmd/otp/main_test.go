@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"otp/internal/store"
+	"otp/internal/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	sinaURL  = "otpauth://totp/Sina:6177236?secret=GEZDGNBVGY3TQOJQ&issuer=Sina"
+	samanURL = "otpauth://hotp/Saman:966775?secret=MFRGGZDFMZTWQ2LK&issuer=Saman&counter=5"
+)
+
+// otp runs the command with a store in dir and returns the exit code and
+// the outputs.
+func otp(t *testing.T, dir, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"-store", filepath.Join(dir, "tokens.json")}, args...)
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	code, stdout, stderr := otp(t, dir, sinaURL+"\n"+samanURL+"\n", "import", "-format", "otpauth", "-")
+	if code != exitOK || stdout != "Imported sina-6177236\nImported saman-966775\n" {
+		t.Fatalf("import : %d %s %s", code, stdout, stderr)
+	}
+
+	code, stdout, stderr = otp(t, dir, "", "list")
+	if code != exitOK || !strings.Contains(stdout, "saman-966775  Saman  966775   hotp") {
+		t.Fatalf("list : %d %s %s", code, stdout, stderr)
+	}
+
+	// Flags after the name, and codes of hotp tokens advance the counter
+	saman, err := token.FromURL(samanURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		expected, err := saman.NextOtp1()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		code, stdout, stderr = otp(t, dir, "", "code", "saman-966775", "-slot", "modern")
+		if code != exitOK || stdout != expected+"\n" {
+			t.Fatalf("code : expected %s, got %d %s %s", expected, code, stdout, stderr)
+		}
+	}
+
+	code, stdout, stderr = otp(t, dir, "", "show", "-qr", "-mode", "ascii", "sina-6177236")
+	if code != exitOK || !strings.Contains(stdout, "modern : "+sinaURL+"&") || !strings.Contains(stdout, "##") {
+		t.Fatalf("show : %d %s %s", code, stdout, stderr)
+	}
+
+	code, stdout, stderr = otp(t, dir, "", "export", "-format", "otpauth", "sina-6177236")
+	if code != exitOK || !strings.HasPrefix(stdout, "otpauth://totp/Sina:6177236?") {
+		t.Fatalf("export : %d %s %s", code, stdout, stderr)
+	}
+
+	code, _, stderr = otp(t, dir, "", "remove", "sina-6177236")
+	if code != exitOK {
+		t.Fatalf("remove : %d %s", code, stderr)
+	}
+
+	code, _, stderr = otp(t, dir, "", "code", "sina-6177236")
+	if code != exitError || !strings.Contains(stderr, "no token with this name") {
+		t.Fatalf("code of a removed token : %d %s", code, stderr)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, args := range [][]string{
+		{},
+		{"unknown"},
+		{"code"},
+		{"code", "-unknown", "name"},
+		{"import", "file.json"},
+		{"activate"},
+		{"show", "-mode", "sixel", "name"},
+	} {
+		if code, _, stderr := otp(t, dir, "", args...); code != exitUsage {
+			t.Errorf("%v : expected exit code %d, got %d %s", args, exitUsage, code, stderr)
+		}
+	}
+
+	code, stdout, _ := otp(t, dir, "", "help", "export")
+	if code != exitOK || !strings.Contains(stdout, "Usage: otp export") || !strings.Contains(stdout, "-format") {
+		t.Fatalf("help : %d %s", code, stdout)
+	}
+}
+
+func TestLossy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := store.Open(filepath.Join(dir, "tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bank := &token.Token{FirstOtpLength: 8, TimeInterval: 30000, BankName: "Sina", AccountId: "6177236", Seed: "3132333435"}
+	if err := s.Add("sina", bank, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := otp(t, dir, "", "show", "sina")
+	if code != exitOK || !strings.Contains(stdout, "pin1 : token codes can not be generated") {
+		t.Fatalf("show : %d %s %s", code, stdout, stderr)
+	}
+
+	code, stdout, stderr = otp(t, dir, "", "show", "-lossy", "sina")
+	if code != exitOK || !strings.Contains(stdout, "pin1 : otpauth://totp/Sina:6177236?") || !strings.Contains(stderr, "differ in authenticator apps") {
+		t.Fatalf("show -lossy : %d %s %s", code, stdout, stderr)
+	}
+
+	code, _, stderr = otp(t, dir, "", "export", "-format", "otpauth", "sina")
+	if code != exitError || !strings.Contains(stderr, "zeros appended") {
+		t.Fatalf("export : %d %s", code, stderr)
+	}
+
+	code, stdout, stderr = otp(t, dir, "", "export", "-format", "otpauth", "-lossy", "sina")
+	if code != exitOK || !strings.HasPrefix(stdout, "otpauth://totp/Sina:6177236?") {
+		t.Fatalf("export -lossy : %d %s %s", code, stdout, stderr)
+	}
+}
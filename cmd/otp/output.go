@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"otp/internal/activation"
+	"otp/internal/interop"
+	"otp/internal/provider"
+	"otp/internal/pskc"
+	"otp/internal/store"
+	"otp/internal/token"
+	"otp/pkg/otpauth"
+	"otp/pkg/qr"
+	"time"
+)
+
+// Values of the -output flag
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// errorCodes are the stable codes of errors in json output, checked in order.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{store.ErrNotFound, "not_found"},
+	{store.ErrExists, "exists"},
+	{store.ErrInvalidName, "invalid_name"},
+	{activation.ErrExpired, "expired"},
+	{activation.ErrVerificationCodeLength, "verification_code_length"},
+	{activation.ErrPinLength, "pin_length"},
+	{activation.ErrBankRequired, "bank_required"},
+	{activation.ErrMissingValue, "missing_value"},
+	{activation.ErrUnknownPayload, "unknown_payload"},
+	{activation.ErrInvalidPayload, "invalid_payload"},
+	{provider.ErrUnknownProvider, "unknown_provider"},
+	{token.ErrWrongPin, "wrong_pin"},
+	{token.ErrUnknownSlot, "unknown_slot"},
+	{token.ErrNotTotp, "not_totp"},
+	{token.ErrNotPortable, "not_portable"},
+	{interop.ErrUnknownFormat, "unknown_format"},
+	{interop.ErrPasswordRequired, "password_required"},
+	{interop.ErrWrongPassword, "wrong_password"},
+	{pskc.ErrKeyRequired, "password_required"},
+	{pskc.ErrDecryption, "wrong_password"},
+	{pskc.ErrInvalidMAC, "wrong_password"},
+	{otpauth.ErrInvalidScheme, "invalid_url"},
+	{qr.ErrNotFound, "qr_not_found"},
+}
+
+// errorOutput is the json output of a failed command.
+type errorOutput struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorCode returns the stable code of an error, or "error" for errors
+// without one.
+func errorCode(err error) string {
+	var usage *usageError
+	if errors.As(err, &usage) {
+		return "usage"
+	}
+
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return "error"
+}
+
+// json reports whether the output is json.
+func (a *app) json() bool {
+	return a.output == outputJSON
+}
+
+// writeJSON writes the json output of a command.
+func (a *app) writeJSON(v interface{}) error {
+	encoder := json.NewEncoder(a.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// tokenOutput describes a saved token without its secrets.
+type tokenOutput struct {
+	Name         string   `json:"name"`
+	Bank         string   `json:"bank"`
+	Account      string   `json:"account"`
+	Type         string   `json:"type"`
+	Slots        []string `json:"slots"`
+	PinProtected bool     `json:"pinProtected"`
+}
+
+func newTokenOutput(name string, t *token.Token) tokenOutput {
+	output := tokenOutput{
+		Name:         name,
+		Bank:         t.BankName,
+		Account:      t.AccountId,
+		Type:         tokenType(t),
+		Slots:        []string{},
+		PinProtected: t.IsPinProtected(),
+	}
+	for _, slot := range t.SlotNames() {
+		output.Slots = append(output.Slots, string(slot))
+	}
+	return output
+}
+
+// codeOutput is a code of a slot. Validity is only set for time based
+// tokens and the counter only for counter based ones.
+type codeOutput struct {
+	Name             string     `json:"name"`
+	Slot             string     `json:"slot"`
+	Code             string     `json:"code"`
+	Previous         string     `json:"previous,omitempty"`
+	Next             string     `json:"next,omitempty"`
+	Counter          *uint64    `json:"counter,omitempty"`
+	ValidFrom        *time.Time `json:"validFrom,omitempty"`
+	ValidUntil       *time.Time `json:"validUntil,omitempty"`
+	RemainingSeconds *int       `json:"remainingSeconds,omitempty"`
+}
+
+func newCodeOutput(name string, slot token.Slot, t *token.Token, code *token.Code, now time.Time) codeOutput {
+	output := codeOutput{Name: name, Slot: string(slot), Code: code.Value}
+	if t.IsHotp() {
+		counter := code.Step
+		output.Counter = &counter
+		return output
+	}
+
+	validFrom, validUntil := code.ValidFrom.UTC(), code.ValidUntil.UTC()
+	remaining := remainingSeconds(code, now)
+	output.ValidFrom, output.ValidUntil, output.RemainingSeconds = &validFrom, &validUntil, &remaining
+	return output
+}
+
+// remainingSeconds is rounded up, so the countdown ends at 1 right before
+// the code changes.
+func remainingSeconds(code *token.Code, now time.Time) int {
+	return int((code.Remaining(now) + time.Second - 1) / time.Second)
+}
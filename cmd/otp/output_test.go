@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var imported struct {
+		Imported []tokenOutput `json:"imported"`
+	}
+	decode(t, dir, sinaURL+"\n"+samanURL+"\n", &imported, "-output", "json", "import", "-format", "otpauth", "-")
+	if len(imported.Imported) != 2 || imported.Imported[1].Name != "saman-966775" || imported.Imported[1].Type != "hotp" {
+		t.Fatalf("wrong import %+v", imported)
+	}
+
+	var list struct {
+		Tokens []tokenOutput `json:"tokens"`
+	}
+	decode(t, dir, "", &list, "list", "-output", "json")
+	if len(list.Tokens) != 2 || list.Tokens[1].Bank != "Sina" || list.Tokens[1].PinProtected {
+		t.Fatalf("wrong list %+v", list)
+	}
+
+	var codes struct {
+		Codes []codeOutput `json:"codes"`
+	}
+	decode(t, dir, "", &codes, "code", "sina-6177236", "-output", "json")
+	sina := codes.Codes[0]
+	if len(codes.Codes) != 1 || sina.Slot != "modern" || len(sina.Code) != 6 || sina.ValidFrom == nil || sina.Counter != nil {
+		t.Fatalf("wrong codes %+v", codes)
+	}
+	if sina.ValidUntil.Sub(*sina.ValidFrom) != 30*time.Second || *sina.RemainingSeconds < 1 || *sina.RemainingSeconds > 30 {
+		t.Fatalf("wrong validity %v - %v, %d", sina.ValidFrom, sina.ValidUntil, *sina.RemainingSeconds)
+	}
+
+	var shifted struct {
+		Codes []codeOutput `json:"codes"`
+	}
+	decode(t, dir, "", &shifted, "code", "sina-6177236", "-output", "json", "-offset", "-1h")
+	if late := time.Since(*shifted.Codes[0].ValidFrom); late < time.Hour-time.Minute || late > time.Hour+time.Minute {
+		t.Fatalf("offset is not used : code is valid from %v", shifted.Codes[0].ValidFrom)
+	}
+
+	var hotpCodes struct {
+		Codes []codeOutput `json:"codes"`
+	}
+	decode(t, dir, "", &hotpCodes, "code", "saman-966775", "-output", "json")
+	if saman := hotpCodes.Codes[0]; saman.Counter == nil || *saman.Counter != 5 || saman.ValidFrom != nil {
+		t.Fatalf("wrong hotp code %+v", saman)
+	}
+
+	var show showOutput
+	decode(t, dir, "", &show, "show", "-output", "json", "-qr", "sina-6177236")
+	if len(show.URLs) != 1 || !strings.HasPrefix(show.URLs[0].URL, sinaURL) || show.Token.Account != "6177236" {
+		t.Fatalf("wrong show %+v", show)
+	}
+
+	var export exportOutput
+	decode(t, dir, "", &export, "export", "-output", "json", "-format", "otpauth", "sina-6177236")
+	if export.Count != 1 || export.Format != "otpauth" || !strings.HasPrefix(export.Data, "otpauth://totp/Sina:6177236?") {
+		t.Fatalf("wrong export %+v", export)
+	}
+
+	code, stdout, _ := otp(t, dir, "", "-output", "json", "watch", "-once")
+	var line struct {
+		Time  time.Time    `json:"time"`
+		Codes []codeOutput `json:"codes"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &line); code != exitOK || err != nil || strings.Count(stdout, "\n") != 1 {
+		t.Fatalf("watch : %d %v %s", code, err, stdout)
+	}
+	if len(line.Codes) != 1 || len(line.Codes[0].Previous) != 6 || len(line.Codes[0].Next) != 6 {
+		t.Fatalf("wrong watch line %s", stdout)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, test := range []struct {
+		args      []string
+		exitCode  int
+		errorCode string
+	}{
+		{[]string{"code", "-output", "json", "missing"}, exitError, "not_found"},
+		{[]string{"-output", "json", "code"}, exitUsage, "usage"},
+		{[]string{"-output", "json", "bogus"}, exitUsage, "usage"},
+		{[]string{"-output", "json"}, exitUsage, "usage"},
+		{[]string{"-output", "json", "-bogus", "list"}, exitUsage, "usage"},
+		{[]string{"-bogus", "list"}, exitUsage, ""},
+		{[]string{"bogus"}, exitUsage, ""},
+		{[]string{"code", "-output", "json", "-unknown", "name"}, exitUsage, "usage"},
+		{[]string{"code", "-output", "xml", "name"}, exitUsage, ""},
+		{[]string{"activate", "-output", "json", "-payload", "hello"}, exitError, "unknown_payload"},
+		{[]string{"activate", "-output", "json", "-payload", `{"channelNameInAAServer":"CARD","cif":"1","pinLength":6,"token":"x","tokenGeneratedTime":1575369617463,"tokenTimeToLiveSeconds":900,"verificationCodeLength":4}`, "-verification-code", "1234", "-pin", "123456"}, exitError, "expired"},
+		{[]string{"activate", "-output", "json", "-payload", "{\"cif\":\"1\",\"token\":\"x\"}", "-verification-code", "1234", "-pin", "123456", "unknown-bank"}, exitError, "unknown_provider"},
+		{[]string{"import", "-output", "json", "-format", "json", "file"}, exitUsage, "usage"},
+	} {
+		code, stdout, stderr := otp(t, dir, "", test.args...)
+
+		if code != test.exitCode {
+			t.Errorf("%v : expected exit code %d, got %d %s", test.args, test.exitCode, code, stderr)
+		}
+
+		// Without a valid -output json, errors are text on stderr
+		if len(test.errorCode) == 0 {
+			if len(stdout) > 0 {
+				t.Errorf("%v : unexpected output %s", test.args, stdout)
+			}
+			continue
+		}
+
+		var output errorOutput
+		if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+			t.Errorf("%v : %v\n%s", test.args, err, stdout)
+			continue
+		}
+
+		if output.Error.Code != test.errorCode || len(output.Error.Message) == 0 {
+			t.Errorf("%v : expected %s, got %+v", test.args, test.errorCode, output)
+		}
+	}
+}
+
+// decode runs the command and decodes its json output.
+func decode(t *testing.T, dir, stdin string, v interface{}, args ...string) {
+	code, stdout, stderr := otp(t, dir, stdin, args...)
+	if code != exitOK {
+		t.Fatalf("%v : %d %s %s", args, code, stdout, stderr)
+	}
+
+	if err := json.Unmarshal([]byte(stdout), v); err != nil {
+		t.Fatalf("%v : %v\n%s", args, err, stdout)
+	}
+}
+
+func TestImportSkipped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backup := `{"version":1,"header":{},"db":{"entries":[{"type":"steam","name":"steam","info":{"secret":"GEZDGNBVGY3TQOJQ"}},{"type":"totp","name":"alice","issuer":"RFC 6238","info":{"secret":"GEZDGNBVGY3TQOJQ"}}]}}`
+
+	var imported struct {
+		Imported []tokenOutput   `json:"imported"`
+		Skipped  []skippedOutput `json:"skipped"`
+	}
+	decode(t, dir, backup, &imported, "-output", "json", "import", "-format", "aegis", "-")
+	if len(imported.Imported) != 1 || len(imported.Skipped) != 1 || imported.Skipped[0].Name != "steam" {
+		t.Fatalf("wrong import %+v", imported)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"otp/internal/token"
+	"otp/pkg/qr"
+)
+
+var showCommand = &command{
+	name:        "show",
+	usage:       "[flags] <name>",
+	description: "Print the otpauth urls of a token, to add it to authenticator apps",
+	run:         runShow,
+}
+
+func runShow(a *app, args []string) error {
+	flags := a.flags()
+	slot := flags.String("slot", "", "slot to show, like pin1, pin2 or modern (default every slot)")
+	pin := flags.String("pin", "", "pin of a protected token (default $"+pinEnv+" or asked)")
+	showQR := flags.Bool("qr", false, "draw the QR code of every url")
+	mode := flags.String("mode", "halfblock", "how QR codes are drawn, halfblock, ansi or ascii")
+	invert := flags.Bool("invert", false, "draw QR codes for terminals with dark text on a light background")
+	quietZone := flags.Int("quiet-zone", 0, "margin around QR codes in modules, -1 for none (default 4)")
+	lossy := flags.Bool("lossy", false, lossyUsage)
+
+	args, err := a.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return usagef("a token name is required")
+	}
+
+	terminalMode, err := qr.ParseTerminalMode(*mode)
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	s, err := a.openStore()
+	if err != nil {
+		return err
+	}
+
+	t, err := s.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	names, err := slots(t, *slot)
+	if err != nil {
+		return err
+	}
+
+	unlocked, err := a.unlock(t, *pin)
+	if err != nil {
+		return err
+	}
+
+	output := showOutput{Token: newTokenOutput(args[0], t), URLs: []urlOutput{}}
+	if !a.json() {
+		fmt.Fprintf(a.stdout, "Bank     %s\nAccount  %s\nType     %s\nSlots    %s\n", t.BankName, t.AccountId, tokenType(t), slotList(t))
+	}
+
+	for _, name := range names {
+		key, err := unlocked.Key(name)
+		var warning string
+		if *lossy && errors.Is(err, token.ErrNotPortable) {
+			key, err = unlocked.LossyKey(name)
+			warning = lossyWarning
+		}
+		if errors.Is(err, token.ErrNotPortable) {
+			output.URLs = append(output.URLs, urlOutput{Slot: string(name), Error: &errorBody{Code: errorCode(err), Message: err.Error()}})
+			if !a.json() {
+				fmt.Fprintf(a.stdout, "\n%s : %v\n", name, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s : %w", name, err)
+		}
+
+		url, err := key.URL()
+		if err != nil {
+			return fmt.Errorf("%s : %w", name, err)
+		}
+
+		// QR codes are not drawn in json
+		output.URLs = append(output.URLs, urlOutput{Slot: string(name), URL: url, Warning: warning})
+		if a.json() {
+			continue
+		}
+		fmt.Fprintf(a.stdout, "\n%s : %s\n", name, url)
+		if len(warning) > 0 {
+			fmt.Fprintf(a.stderr, "otp show: %s : %s\n", name, warning)
+		}
+
+		if !*showQR {
+			continue
+		}
+
+		code, err := qr.Encode(url, nil)
+		if err != nil {
+			return err
+		}
+
+		err = code.WriteTerminal(a.stdout, &qr.TerminalOptions{Mode: terminalMode, Invert: *invert, QuietZone: *quietZone})
+		if err != nil {
+			return err
+		}
+	}
+
+	if a.json() {
+		return a.writeJSON(output)
+	}
+	return nil
+}
+
+// showOutput is the json output of show. Slots that authenticator apps can
+// not generate have an error instead of a url.
+type showOutput struct {
+	Token tokenOutput `json:"token"`
+	URLs  []urlOutput `json:"urls"`
+}
+
+type urlOutput struct {
+	Slot    string     `json:"slot"`
+	URL     string     `json:"url,omitempty"`
+	Warning string     `json:"warning,omitempty"`
+	Error   *errorBody `json:"error,omitempty"`
+}
+
+// lossyUsage is the usage of the -lossy flag of show and export.
+const lossyUsage = "also convert tokens that append zeros to short codes, whose codes then differ in authenticator apps about one time in ten"
+
+// lossyWarning is shown for urls of tokens that append zeros.
+const lossyWarning = "codes that start with a zero differ in authenticator apps"
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"otp/internal/utils"
+	"time"
+)
+
+var syncCommand = &command{
+	name:        "sync",
+	usage:       "[name]...",
+	description: "Estimate the clock of the bank server of tokens",
+	run:         runSync,
+}
+
+// syncOutput is the estimated clock offset of the server of a token.
+type syncOutput struct {
+	Name               string `json:"name"`
+	Host               string `json:"host"`
+	OffsetMilliseconds int64  `json:"offsetMilliseconds"`
+}
+
+func runSync(a *app, args []string) error {
+	args, err := a.parse(a.flags(), args)
+	if err != nil {
+		return err
+	}
+
+	s, err := a.openStore()
+	if err != nil {
+		return err
+	}
+
+	// Without names, every token of a bank server is synced
+	names := args
+	if len(names) == 0 {
+		for _, name := range s.Names() {
+			t, err := s.Get(name)
+			if err != nil {
+				return err
+			}
+			if len(t.ServerHost) > 0 {
+				names = append(names, name)
+			}
+		}
+	}
+
+	synced := []syncOutput{}
+	for _, name := range names {
+		t, err := s.Get(name)
+		if err != nil {
+			return err
+		}
+		if len(t.ServerHost) == 0 {
+			return fmt.Errorf("%s : token has no bank server", name)
+		}
+
+		if _, err := utils.SyncClock("https://" + t.ServerHost); err != nil {
+			return fmt.Errorf("%s : %w", name, err)
+		}
+		t.SyncServerTime()
+
+		synced = append(synced, syncOutput{Name: name, Host: t.ServerHost, OffsetMilliseconds: int64(t.ServerOffset / time.Millisecond)})
+		if !a.json() {
+			fmt.Fprintf(a.stdout, "%s %s %v\n", name, t.ServerHost, t.ServerOffset.Round(time.Millisecond))
+		}
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	if a.json() {
+		return a.writeJSON(struct {
+			Synced []syncOutput `json:"synced"`
+		}{Synced: synced})
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"otp/internal/store"
+	"otp/internal/token"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSync(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "otp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := store.Open(filepath.Join(dir, "tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := strings.TrimPrefix(server.URL, "https://")
+	if err := s.Add("bank", &token.Token{Seed: "3132333435", FirstOtpLength: 6, TimeInterval: 30000, ServerHost: host}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var synced struct {
+		Synced []syncOutput `json:"synced"`
+	}
+	decode(t, dir, "", &synced, "-output", "json", "sync")
+	if len(synced.Synced) != 1 || synced.Synced[0].Host != host {
+		t.Fatalf("wrong sync %+v", synced)
+	}
+	if offset := time.Duration(synced.Synced[0].OffsetMilliseconds) * time.Millisecond; offset < 59*time.Minute || offset > 61*time.Minute {
+		t.Fatalf("wrong offset %v", offset)
+	}
+
+	s, err = store.Open(filepath.Join(dir, "tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := s.Get("bank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.UseServerTime || saved.ServerOffset < 59*time.Minute {
+		t.Fatalf("offset is not saved : %+v", saved)
+	}
+
+	code, _, stderr := otp(t, dir, sinaURL+"\n", "import", "-format", "otpauth", "-")
+	if code != exitOK {
+		t.Fatalf("import : %d %s", code, stderr)
+	}
+	if code, _, stderr := otp(t, dir, "", "sync", "sina-6177236"); code != exitError || !strings.Contains(stderr, "no bank server") {
+		t.Fatalf("sync of an authenticator token : %d %s", code, stderr)
+	}
+}
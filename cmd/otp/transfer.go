@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"otp/internal/interop"
+	"otp/internal/pskc"
+	"otp/internal/token"
+	"strings"
+)
+
+// formatPSKC is the name of RFC 6030 key containers, next to the formats of
+// interop.
+const formatPSKC = "pskc"
+
+// skippedOutput is an entry of a backup that is not imported.
+type skippedOutput struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+var importCommand = &command{
+	name:        "import",
+	usage:       "[flags] <file>",
+	description: "Import tokens from a backup of an authenticator app or a pskc file",
+	run:         runImport,
+}
+
+var exportCommand = &command{
+	name:        "export",
+	usage:       "[flags] [name...]",
+	description: "Export tokens for an authenticator app or as a pskc file",
+	run:         runExport,
+}
+
+func formatNames() string {
+	var names []string
+	for _, format := range interop.Formats {
+		names = append(names, string(format))
+	}
+	return strings.Join(append(names, formatPSKC), ", ")
+}
+
+func runImport(a *app, args []string) error {
+	flags := a.flags()
+	format := flags.String("format", "", "format of the file, one of "+formatNames())
+	password := flags.String("password", "", "password of an encrypted aegis backup or pskc file")
+	key := flags.String("key", "", "hex encoded pre-shared key of a pskc file")
+
+	args, err := a.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return usagef("a file is required, - reads standard input")
+	}
+	if len(*format) == 0 {
+		return usagef("-format is required")
+	}
+
+	isPSKC := strings.EqualFold(*format, formatPSKC)
+	var f interop.Format
+	if !isPSKC {
+		if f, err = interop.ParseFormat(*format); err != nil {
+			return usagef("%v", err)
+		}
+	}
+
+	var data []byte
+	if args[0] == "-" {
+		data, err = ioutil.ReadAll(a.stdin)
+	} else {
+		data, err = ioutil.ReadFile(args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	var tokens []*token.Token
+	skipped := []skippedOutput{}
+	if isPSKC {
+		options, err := pskcOptions(*key, *password)
+		if err != nil {
+			return err
+		}
+
+		packages, err := pskc.Read(data, options)
+		if err != nil {
+			return err
+		}
+		tokens = pskc.Tokens(packages)
+	} else {
+		keys, skippedEntries, err := interop.Import(f, data, *password)
+		if err != nil {
+			return err
+		}
+		tokens = interop.TokensFromKeys(keys)
+
+		for _, entry := range skippedEntries {
+			skipped = append(skipped, skippedOutput{Name: entry.Name, Reason: entry.Err.Error()})
+		}
+	}
+
+	s, err := a.openStore()
+	if err != nil {
+		return err
+	}
+
+	imported := []tokenOutput{}
+	for _, t := range tokens {
+		name := s.NameFor(t)
+		if err := s.Add(name, t, false); err != nil {
+			return err
+		}
+		imported = append(imported, newTokenOutput(name, t))
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	if a.json() {
+		return a.writeJSON(struct {
+			Imported []tokenOutput   `json:"imported"`
+			Skipped  []skippedOutput `json:"skipped"`
+		}{Imported: imported, Skipped: skipped})
+	}
+
+	for _, t := range imported {
+		fmt.Fprintf(a.stdout, "Imported %s\n", t.Name)
+	}
+	for _, entry := range skipped {
+		fmt.Fprintf(a.stdout, "Skipped %s : %s\n", entry.Name, entry.Reason)
+	}
+	return nil
+}
+
+func runExport(a *app, args []string) error {
+	flags := a.flags()
+	format := flags.String("format", "", "format of the file, one of "+formatNames())
+	password := flags.String("password", "", "encrypt an aegis backup or pskc file with a password")
+	key := flags.String("key", "", "hex encoded pre-shared key to encrypt a pskc file")
+	file := flags.String("file", "", "file to write (default standard output)")
+	pin := flags.String("pin", "", "pin of protected tokens (default $"+pinEnv+" or asked)")
+	lossy := flags.Bool("lossy", false, lossyUsage)
+
+	args, err := a.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(*format) == 0 {
+		return usagef("-format is required")
+	}
+
+	s, err := a.openStore()
+	if err != nil {
+		return err
+	}
+
+	names := args
+	if len(names) == 0 {
+		names = s.Names()
+	}
+
+	var tokens []*token.Token
+	for _, name := range names {
+		t, err := s.Get(name)
+		if err != nil {
+			return err
+		}
+
+		unlocked, err := a.unlock(t, *pin)
+		if err != nil {
+			return fmt.Errorf("%s : %w", name, err)
+		}
+		tokens = append(tokens, unlocked)
+	}
+
+	var data []byte
+	var count int
+	if strings.EqualFold(*format, formatPSKC) {
+		options, err := pskcOptions(*key, *password)
+		if err != nil {
+			return err
+		}
+
+		packages, err := pskc.Packages(tokens, *lossy)
+		if err != nil {
+			return err
+		}
+		count = len(packages)
+
+		data, err = pskc.Write(packages, options)
+		if err != nil {
+			return err
+		}
+	} else {
+		f, err := interop.ParseFormat(*format)
+		if err != nil {
+			return usagef("%v", err)
+		}
+
+		keys, err := interop.KeysFromTokens(tokens, *lossy)
+		if err != nil {
+			return err
+		}
+		count = len(keys)
+
+		data, err = interop.Export(f, keys, *password)
+		if err != nil {
+			return err
+		}
+	}
+
+	output := exportOutput{Format: strings.ToLower(*format), File: *file, Count: count}
+	if len(*file) > 0 {
+		if err := ioutil.WriteFile(*file, data, 0600); err != nil {
+			return err
+		}
+	} else if !a.json() {
+		_, err = a.stdout.Write(data)
+		return err
+	} else {
+		output.Data = string(data)
+	}
+
+	if a.json() {
+		return a.writeJSON(output)
+	}
+	return nil
+}
+
+// exportOutput is the json output of export. Data is only set without a
+// file.
+type exportOutput struct {
+	Format string `json:"format"`
+	File   string `json:"file,omitempty"`
+	Count  int    `json:"count"`
+	Data   string `json:"data,omitempty"`
+}
+
+func pskcOptions(key, password string) (pskc.Options, error) {
+	options := pskc.Options{Password: password}
+	if len(key) > 0 {
+		var err error
+		options.Key, err = hex.DecodeString(key)
+		if err != nil {
+			return options, usagef("-key is not hex : %v", err)
+		}
+	}
+	return options, nil
+}
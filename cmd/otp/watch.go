@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"otp/internal/token"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var watchCommand = &command{
+	name:        "watch",
+	usage:       "[flags] [name...]",
+	description: "Keep showing the current codes of time based tokens, press enter to show the previous and next codes",
+	run:         runWatch,
+}
+
+const (
+	ansiUp        = "\x1b[%dA"
+	ansiClearLine = "\x1b[K"
+)
+
+// watchRow is a slot of a token that is watched.
+type watchRow struct {
+	name  string
+	slot  token.Slot
+	token *token.Token
+
+	// offset is how far the token clock is from the system clock
+	offset time.Duration
+}
+
+// at returns the time of the token clock at the system time now.
+func (r watchRow) at(now time.Time) time.Time {
+	return now.Add(r.offset)
+}
+
+func runWatch(a *app, args []string) error {
+	flags := a.flags()
+	slot := flags.String("slot", "", "slot to watch, like pin1, pin2 or modern (default every slot)")
+	pin := flags.String("pin", "", "pin of protected tokens (default $"+pinEnv+" or asked)")
+	adjacent := flags.Bool("adjacent", false, "show the previous and next codes from the start")
+	once := flags.Bool("once", false, "print the codes once and exit")
+	offset := offsetFlag(flags)
+
+	args, err := a.parse(flags, args)
+	if err != nil {
+		return err
+	}
+
+	s, err := a.openStore()
+	if err != nil {
+		return err
+	}
+
+	names := args
+	if len(names) == 0 {
+		names = s.Names()
+	}
+
+	var rows []watchRow
+	for _, name := range names {
+		t, err := s.Get(name)
+		if err != nil {
+			return err
+		}
+
+		// Only tokens named on the command line are reported as hotp
+		if t.IsHotp() {
+			if len(args) > 0 {
+				return fmt.Errorf("%s : %w", name, token.ErrNotTotp)
+			}
+			continue
+		}
+
+		slotNames, err := slots(t, *slot)
+		if errors.Is(err, token.ErrUnknownSlot) && len(args) == 0 {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s : %w", name, err)
+		}
+
+		unlocked, err := a.unlock(t, *pin)
+		if err != nil {
+			return fmt.Errorf("%s : %w", name, err)
+		}
+
+		// Tokens of different banks can follow different clocks. Offsets
+		// are rounded, so tokens of the system clock refresh on the second.
+		unlocked = withOffset(unlocked, *offset)
+		tokenOffset := unlocked.Now().Sub(time.Now()).Round(time.Millisecond)
+
+		for _, slotName := range slotNames {
+			rows = append(rows, watchRow{name: name, slot: slotName, token: unlocked, offset: tokenOffset})
+		}
+	}
+
+	if len(rows) == 0 {
+		return errors.New("no time based tokens to watch")
+	}
+
+	if *once {
+		frame, err := a.watchOutput(rows, time.Now(), *adjacent)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(a.stdout, frame)
+		return err
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	// Every line of input toggles the previous and next codes
+	toggle := make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(a.stdin)
+		for scanner.Scan() {
+			toggle <- struct{}{}
+		}
+	}()
+
+	lines := 0
+	var lastSteps string
+	for {
+		now := time.Now()
+		frame, err := a.watchOutput(rows, now, *adjacent)
+		if err != nil {
+			return err
+		}
+
+		// Json lines are only printed when a code changes
+		if a.json() {
+			if steps := watchSteps(rows, now); steps != lastSteps {
+				fmt.Fprint(a.stdout, frame)
+				lastSteps = steps
+			}
+		} else {
+			a.redraw(frame, lines)
+			lines = strings.Count(frame, "\n")
+		}
+
+		timer := time.NewTimer(nextRefresh(rows, now).Sub(time.Now()))
+		select {
+		case <-timer.C:
+		case <-toggle:
+			timer.Stop()
+			*adjacent = !*adjacent
+			lines++
+		case <-interrupt:
+			timer.Stop()
+			return nil
+		}
+	}
+}
+
+// watchOutput returns the codes at now as a table or a line of json.
+func (a *app) watchOutput(rows []watchRow, now time.Time, adjacent bool) (string, error) {
+	if a.json() {
+		return watchLine(rows, now)
+	}
+	return watchFrame(rows, now, adjacent)
+}
+
+// redraw draws a frame over the previous one of the given number of lines.
+func (a *app) redraw(frame string, lines int) {
+	var b strings.Builder
+	if lines > 0 {
+		fmt.Fprintf(&b, ansiUp, lines)
+	}
+	for _, line := range strings.SplitAfter(frame, "\n") {
+		if len(line) > 0 {
+			b.WriteString(strings.TrimSuffix(line, "\n") + ansiClearLine + "\n")
+		}
+	}
+	fmt.Fprint(a.stdout, b.String())
+}
+
+// watchCodes returns the codes of the rows at the system time now, with the
+// previous and next codes.
+func watchCodes(rows []watchRow, now time.Time) ([]codeOutput, error) {
+	var codes []codeOutput
+	for _, row := range rows {
+		code, err := row.token.CodeAt(row.slot, row.at(now))
+		if err != nil {
+			return nil, fmt.Errorf("%s %s : %w", row.name, row.slot, err)
+		}
+
+		previous, err := row.token.CodeAt(row.slot, code.ValidFrom.Add(-time.Second))
+		if err != nil {
+			return nil, err
+		}
+		next, err := row.token.CodeAt(row.slot, code.ValidUntil)
+		if err != nil {
+			return nil, err
+		}
+
+		output := newCodeOutput(row.name, row.slot, row.token, code, row.at(now))
+		output.Previous, output.Next = previous.Value, next.Value
+		codes = append(codes, output)
+	}
+	return codes, nil
+}
+
+// watchFrame returns the table of the codes at now.
+func watchFrame(rows []watchRow, now time.Time, adjacent bool) (string, error) {
+	codes, err := watchCodes(rows, now)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	if adjacent {
+		fmt.Fprintln(w, "NAME\tSLOT\tPREVIOUS\tCODE\tNEXT\tLEFT")
+	} else {
+		fmt.Fprintln(w, "NAME\tSLOT\tCODE\tLEFT")
+	}
+
+	for _, c := range codes {
+		if adjacent {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%ds\n", c.Name, c.Slot, c.Previous, c.Code, c.Next, *c.RemainingSeconds)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%ds\n", c.Name, c.Slot, c.Code, *c.RemainingSeconds)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// watchLine returns the codes at now as a line of json.
+func watchLine(rows []watchRow, now time.Time) (string, error) {
+	codes, err := watchCodes(rows, now)
+	if err != nil {
+		return "", err
+	}
+
+	line, err := json.Marshal(struct {
+		Time  time.Time    `json:"time"`
+		Codes []codeOutput `json:"codes"`
+	}{Time: now.UTC(), Codes: codes})
+	if err != nil {
+		return "", err
+	}
+	return string(line) + "\n", nil
+}
+
+// watchSteps returns the time steps of the rows at now.
+func watchSteps(rows []watchRow, now time.Time) string {
+	var b strings.Builder
+	for _, row := range rows {
+		if code, err := row.token.CodeAt(row.slot, row.at(now)); err == nil {
+			fmt.Fprintf(&b, "%d ", code.Step)
+		}
+	}
+	return b.String()
+}
+
+// nextRefresh returns the system time of the next whole second of any row
+// clock after now. Time steps are whole seconds, so the codes change exactly
+// at a refresh.
+func nextRefresh(rows []watchRow, now time.Time) time.Time {
+	var next time.Time
+	for _, row := range rows {
+		refresh := row.at(now).Truncate(time.Second).Add(time.Second).Add(-row.offset)
+		if next.IsZero() || refresh.Before(next) {
+			next = refresh
+		}
+	}
+	return next
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"otp/internal/token"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchFrame(t *testing.T) {
+	bank := &token.Token{
+		FirstOtpLength:  8,
+		SecondOtpLength: 6,
+		TimeInterval:    30000,
+		Seed:            hex.EncodeToString([]byte("12345678901234567890")),
+	}
+	rows := []watchRow{
+		{name: "bank", slot: token.Pin1, token: bank},
+		{name: "bank", slot: token.Pin2, token: bank},
+	}
+
+	// Codes of RFC 6238 at 59 and 1111111109, with the zeros of the bank
+	// profile appended
+	frame, err := watchFrame(rows, time.Unix(59, 0), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "NAME  SLOT  CODE      LEFT\nbank  pin1  94287082  1s\nbank  pin2  287082    1s\n"
+	if frame != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, frame)
+	}
+
+	frame, err = watchFrame(rows, time.Unix(1111111109, 0), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(frame, "bank  pin1  ") || !strings.Contains(frame, "70818040  ") || !strings.HasSuffix(frame, "  1s\n") {
+		t.Fatalf("wrong frame\n%s", frame)
+	}
+
+	previous, err := watchFrame(rows, time.Unix(1111111079, 0), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(frame, strings.Fields(strings.Split(previous, "\n")[1])[2]+"  70818040") {
+		t.Fatalf("previous code is missing\n%s\n%s", previous, frame)
+	}
+
+	if next := nextRefresh(rows, time.Unix(59, 400000000)); !next.Equal(time.Unix(60, 0)) {
+		t.Fatalf("expected a refresh at the step boundary, got %v", next)
+	}
+
+	// A token whose clock is behind shows the codes of its own clock
+	behind := []watchRow{
+		{name: "bank", slot: token.Pin1, token: bank, offset: -30 * time.Second},
+		{name: "bank", slot: token.Pin2, token: bank, offset: -30 * time.Second},
+	}
+	frame, err = watchFrame(behind, time.Unix(89, 0), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, frame)
+	}
+
+	behind[0].offset = -500 * time.Millisecond
+	if next := nextRefresh(behind, time.Unix(59, 400000000)); !next.Equal(time.Unix(59, 500000000)) {
+		t.Fatalf("expected a refresh at the second of the token clock, got %v", next)
+	}
+}
+
+func TestWatchOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	code, stdout, stderr := otp(t, dir, "", "watch", "-once")
+	if code != exitError || !strings.Contains(stderr, "no time based tokens") {
+		t.Fatalf("watch of an empty store : %d %s %s", code, stdout, stderr)
+	}
+
+	otp(t, dir, sinaURL+"\n"+samanURL+"\n", "import", "-format", "otpauth", "-")
+
+	// The hotp token is left out
+	code, stdout, stderr = otp(t, dir, "", "watch", "-once")
+	if code != exitOK || !strings.Contains(stdout, "sina-6177236  modern") || strings.Contains(stdout, "saman") {
+		t.Fatalf("watch : %d %s %s", code, stdout, stderr)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"golang.org/x/text/encoding/unicode/utf32"
+	"otp/internal/clock"
 	"otp/internal/tejarat"
 	"otp/internal/token"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHamraz(t *testing.T) {
@@ -30,7 +32,7 @@ func TestHamraz(t *testing.T) {
 }
 
 func TestOtp(t *testing.T) {
-	// This will only pass for time = 1597087430
+	// 63212052 is shown at 1597087430 in the app
 	otp := token.Token{
 		FirstOtpLength:  8,
 		SecondOtpLength: 8,
@@ -40,6 +42,7 @@ func TestOtp(t *testing.T) {
 		BankName:        "Tejarat",
 		AccountId:       "5859831165750521",
 		Seed:            "1003450364" + "2523C3EEAC8052242627", // 30 chars
+		Clock:           clock.Fixed(time.Unix(1597087430, 0)),
 	}
 
 	b := []byte(otp.Seed)
@@ -7,6 +7,7 @@ require (
 	github.com/eliukblau/pixterm v1.3.1
 	github.com/google/uuid v1.1.1
 	github.com/makiuchi-d/gozxing v0.0.0-20190830103442-eaff64b1ceb7
+	golang.org/x/crypto v0.0.0-20200317142112-1b76d66859c6
 	golang.org/x/text v0.3.2
 	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
 )
@@ -0,0 +1,195 @@
+// Package activation turns a scanned QR code into tokens.
+//
+// Activation codes of banks are validated, sent to the provider of the bank
+// and exchanged for a token. Otpauth and migration urls of authenticator
+// apps already carry their secrets and are converted without a provider.
+package activation
+
+import (
+	"errors"
+	"fmt"
+	"otp/internal/clock"
+	"otp/internal/provider"
+	"otp/internal/token"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBankRequired = errors.New("bank of the activation QR code is required")
+	ErrMissingValue = errors.New("a value is missing")
+)
+
+// channelProviders are the providers whose activation codes can be told
+// apart by their channel name. Other banks use CARD and need Request.Bank.
+var channelProviders = map[string]string{
+	"MODERN":      "sina",
+	"CARD_SECOND": "sina",
+}
+
+// Request holds what the user adds to an activation code.
+type Request struct {
+	// Bank is the ID or name of the provider. It can be empty if the
+	// channel name of the code tells the bank.
+	Bank string
+
+	// VerificationCode is the code the bank sent by sms.
+	VerificationCode string
+
+	// Pin protects the token. Its length is checked against the code.
+	Pin string
+
+	// Values are passed to the session for fields that are not in the
+	// code, like the mobile number of Apan.
+	Values map[string]string
+
+	// Prompt, if set, is asked for required fields that are neither in the
+	// code nor in Values.
+	Prompt func(field provider.Field) (string, error)
+
+	Options provider.Options
+}
+
+// SelectProvider returns the provider of an activation code. The bank of the
+// request takes precedence over the channel name of the code.
+func SelectProvider(payload *Payload, bank string) (provider.Provider, error) {
+	if len(strings.TrimSpace(bank)) > 0 {
+		return provider.Get(bank)
+	}
+
+	if payload.QrData != nil {
+		channel := strings.ToUpper(strings.TrimSpace(payload.QrData.ChannelNameInAAServer))
+		if id, ok := channelProviders[channel]; ok {
+			return provider.Get(id)
+		}
+	}
+
+	return nil, ErrBankRequired
+}
+
+// Activate parses a scanned payload and returns its tokens. An activation
+// code is validated and activated by its provider, which returns a single
+// token. Otpauth urls return one token and migration urls one per key.
+func Activate(text string, request Request) ([]*token.Token, error) {
+	payload, err := ParsePayload(text)
+	if err != nil {
+		return nil, err
+	}
+	return ActivatePayload(payload, request)
+}
+
+// ActivatePayload returns the tokens of a parsed payload, see Activate.
+func ActivatePayload(payload *Payload, request Request) ([]*token.Token, error) {
+	if payload.QrData == nil {
+		tokens := make([]*token.Token, 0, len(payload.Keys))
+		for _, key := range payload.Keys {
+			tokens = append(tokens, token.FromKey(key))
+		}
+		return tokens, nil
+	}
+
+	c := request.Options.Clock
+	if c == nil {
+		c = clock.System
+	}
+
+	if err := payload.Validate(c.Now(), request.VerificationCode, request.Pin); err != nil {
+		return nil, err
+	}
+
+	p, err := SelectProvider(payload, request.Bank)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := run(p, payloadValues(payload, request), request)
+	if err != nil {
+		return nil, fmt.Errorf("%s : %w", p.Name(), err)
+	}
+	return []*token.Token{t}, nil
+}
+
+// ActivateBank activates a token of a bank without an activation code, like
+// the banks that send everything by sms. Values the request does not have
+// are asked from Prompt.
+func ActivateBank(bank string, request Request) (*token.Token, error) {
+	p, err := provider.Get(bank)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	if len(request.VerificationCode) > 0 {
+		values[provider.FieldVerificationCode] = request.VerificationCode
+	}
+	if len(request.Pin) > 0 {
+		values[provider.FieldPin] = request.Pin
+	}
+	for k, v := range request.Values {
+		values[k] = v
+	}
+
+	t, err := run(p, values, request)
+	if err != nil {
+		return nil, fmt.Errorf("%s : %w", p.Name(), err)
+	}
+	return t, nil
+}
+
+// run drives a session of the provider until it returns a token.
+func run(p provider.Provider, values map[string]string, request Request) (*token.Token, error) {
+	session, err := p.NewSession(request.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	for !session.Done() {
+		submitted := make(map[string]string)
+		for _, field := range session.Fields() {
+			value, ok := values[field.Name]
+			if !ok && request.Prompt != nil {
+				value, err = request.Prompt(field)
+				if err != nil {
+					return nil, err
+				}
+				ok = len(value) > 0
+			}
+
+			if !ok && !field.Optional {
+				return nil, fmt.Errorf("%w : %s (%s)", ErrMissingValue, field.Name, field.Description)
+			}
+			submitted[field.Name] = value
+		}
+
+		if err := session.Submit(submitted); err != nil {
+			return nil, err
+		}
+	}
+
+	return session.Token()
+}
+
+// payloadValues returns the session values of the code and the request.
+// Values of the request override the code.
+func payloadValues(payload *Payload, request Request) map[string]string {
+	qrData := payload.QrData
+	values := map[string]string{
+		provider.FieldToken:              qrData.Token,
+		provider.FieldCif:                qrData.Cif,
+		provider.FieldTokenGeneratedTime: strconv.FormatInt(qrData.TokenGeneratedTime, 10),
+		provider.FieldVerificationCode:   request.VerificationCode,
+		provider.FieldPin:                request.Pin,
+	}
+
+	if len(qrData.ChannelNameInAAServer) > 0 {
+		values[provider.FieldChannelNameInAAServer] = qrData.ChannelNameInAAServer
+	}
+	if len(qrData.ServiceChannelOtpType) > 0 {
+		values[provider.FieldServiceChannelOtpType] = qrData.ServiceChannelOtpType
+	}
+
+	for k, v := range request.Values {
+		values[k] = v
+	}
+	return values
+}
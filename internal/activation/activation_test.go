@@ -0,0 +1,221 @@
+package activation
+
+import (
+	"errors"
+	"otp/internal/clock"
+	"otp/internal/provider"
+	"otp/internal/token"
+	"otp/pkg/otpauth"
+	"testing"
+	"time"
+)
+
+const qrDataJSON = `{"channelNameInAAServer":"CARD","cif":"6177236","pinLength":6,"token":"546fac76-1a2c-4764-97b4-e5bb682bb811","tokenGeneratedTime":1575369617463,"tokenTimeToLiveSeconds":900,"verificationCodeLength":4,"version":3}`
+
+// generated is the tokenGeneratedTime of qrDataJSON
+var generated = time.Unix(1575369617, 463000000)
+
+// testProvider records the values it is activated with.
+type testProvider struct {
+	values map[string]string
+}
+
+func (*testProvider) ID() string   { return "activation-test" }
+func (*testProvider) Name() string { return "Activation Test" }
+
+func (p *testProvider) NewSession(provider.Options) (provider.Session, error) {
+	return provider.NewFlow(provider.Step{
+		Fields: []provider.Field{
+			{Name: provider.FieldToken},
+			{Name: provider.FieldCif},
+			{Name: provider.FieldChannelNameInAAServer},
+			{Name: provider.FieldVerificationCode},
+			{Name: provider.FieldPin, Secret: true},
+			{Name: provider.FieldMobileNumber},
+		},
+		Run: func(values map[string]string) (*token.Token, error) {
+			p.values = values
+			return &token.Token{AccountId: values[provider.FieldCif], Seed: "3132333435363738393031323334353637383930"}, nil
+		},
+	}), nil
+}
+
+var fake = &testProvider{}
+
+func init() {
+	provider.Register(fake)
+}
+
+func TestDetect(t *testing.T) {
+	for text, kind := range map[string]Kind{
+		qrDataJSON: KindQrData,
+		"otpauth://totp/Sina:6177236?secret=GEZDGNBVGY3TQOJQ":          KindOtpAuth,
+		" OTPAUTH-MIGRATION://offline?data=CgA%3D ":                    KindMigration,
+		`{"name":"not an activation code"}`:                            KindUnknown,
+		"https://example.com/otpauth://totp/a?secret=GEZDGNBVGY3TQOJQ": KindUnknown,
+	} {
+		if got := Detect(text); got != kind {
+			t.Errorf("%s : expected %s, got %s", text, kind, got)
+		}
+	}
+
+	if _, err := ParsePayload("hello"); !errors.Is(err, ErrUnknownPayload) {
+		t.Fatalf("expected ErrUnknownPayload, got %v", err)
+	}
+
+	if _, err := ParsePayload(`{"token":"","cif":"1"}`); !errors.Is(err, ErrInvalidPayload) {
+		t.Fatalf("expected ErrInvalidPayload, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	payload, err := ParsePayload(qrDataJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !payload.Expiry().Equal(generated.Add(900 * time.Second)) {
+		t.Fatalf("wrong expiry %v", payload.Expiry())
+	}
+
+	now := generated.Add(time.Minute)
+	for _, test := range []struct {
+		now              time.Time
+		verificationCode string
+		pin              string
+		err              error
+	}{
+		{now, "8836", "150968", nil},
+		{generated.Add(901 * time.Second), "8836", "150968", ErrExpired},
+		{now, "883", "150968", ErrVerificationCodeLength},
+		{now, "88a6", "150968", ErrVerificationCodeLength},
+		{now, "8836", "1509", ErrPinLength},
+	} {
+		if err := payload.Validate(test.now, test.verificationCode, test.pin); !errors.Is(err, test.err) {
+			t.Errorf("%s %s : expected %v, got %v", test.verificationCode, test.pin, test.err, err)
+		}
+	}
+}
+
+func TestActivate(t *testing.T) {
+	request := Request{
+		Bank:             "activation test",
+		VerificationCode: "8836",
+		Pin:              "150968",
+		Values:           map[string]string{provider.FieldMobileNumber: "09120000000"},
+		Options:          provider.Options{Clock: clock.Fixed(generated.Add(time.Minute))},
+	}
+
+	tokens, err := Activate(qrDataJSON, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tokens) != 1 || tokens[0].AccountId != "6177236" {
+		t.Fatalf("wrong tokens %+v", tokens)
+	}
+
+	for field, value := range map[string]string{
+		provider.FieldToken:                 "546fac76-1a2c-4764-97b4-e5bb682bb811",
+		provider.FieldChannelNameInAAServer: "CARD",
+		provider.FieldVerificationCode:      "8836",
+		provider.FieldPin:                   "150968",
+		provider.FieldMobileNumber:          "09120000000",
+	} {
+		if fake.values[field] != value {
+			t.Errorf("%s : expected %s, got %s", field, value, fake.values[field])
+		}
+	}
+
+	request.Values = nil
+	if _, err := Activate(qrDataJSON, request); !errors.Is(err, ErrMissingValue) {
+		t.Fatalf("expected ErrMissingValue, got %v", err)
+	}
+
+	request.Prompt = func(field provider.Field) (string, error) {
+		return "09121111111", nil
+	}
+	if _, err := Activate(qrDataJSON, request); err != nil || fake.values[provider.FieldMobileNumber] != "09121111111" {
+		t.Fatalf("prompt was not used : %v", err)
+	}
+
+	request.Bank = ""
+	if _, err := Activate(qrDataJSON, request); !errors.Is(err, ErrBankRequired) {
+		t.Fatalf("expected ErrBankRequired, got %v", err)
+	}
+
+	request.Options.Clock = clock.Fixed(generated.Add(time.Hour))
+	if _, err := Activate(qrDataJSON, request); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected ErrExpired, got %v", err)
+	}
+}
+
+func TestSelectProvider(t *testing.T) {
+	payload, err := ParsePayload(`{"channelNameInAAServer":"MODERN","cif":"3922880","token":"5fc33039-0ad1-4920-875d-6fc2a9840ce8"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sina is not registered in this test
+	if _, err := SelectProvider(payload, ""); !errors.Is(err, provider.ErrUnknownProvider) {
+		t.Fatalf("expected ErrUnknownProvider for sina, got %v", err)
+	}
+
+	p, err := SelectProvider(payload, "activation-test")
+	if err != nil || p != fake {
+		t.Fatalf("expected the bank of the request, got %v %v", p, err)
+	}
+}
+
+func TestActivateOtpAuth(t *testing.T) {
+	tokens, err := Activate("otpauth://totp/Sina:6177236?secret=GEZDGNBVGY3TQOJQ&digits=8", Request{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tokens) != 1 || tokens[0].BankName != "Sina" || tokens[0].OtpLength != 8 {
+		t.Fatalf("wrong tokens %+v", tokens)
+	}
+
+	key, err := otpauth.Parse("otpauth://totp/Saman:1234?secret=MFRGGZDFMZTWQ2LK")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	urls, err := otpauth.MigrationURLs([]*otpauth.Key{key, key}, otpauth.MigrationBatchSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err = Activate(urls[0], Request{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tokens) != 2 || tokens[1].AccountId != "1234" {
+		t.Fatalf("wrong migration tokens %+v", tokens)
+	}
+}
+
+func TestActivateBank(t *testing.T) {
+	var asked []string
+	activated, err := ActivateBank("activation-test", Request{
+		VerificationCode: "8836",
+		Prompt: func(field provider.Field) (string, error) {
+			asked = append(asked, field.Name)
+			return "1", nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if activated.AccountId != "1" || fake.values[provider.FieldVerificationCode] != "8836" {
+		t.Fatalf("wrong token %+v", activated)
+	}
+
+	expected := []string{provider.FieldToken, provider.FieldCif, provider.FieldChannelNameInAAServer, provider.FieldPin, provider.FieldMobileNumber}
+	if len(asked) != len(expected) {
+		t.Fatalf("expected to be asked %v, got %v", expected, asked)
+	}
+}
@@ -0,0 +1,191 @@
+package activation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"net/url"
+	. "otp/internal/structs"
+	"otp/pkg/otpauth"
+	"otp/pkg/qr"
+	"strings"
+	"time"
+)
+
+// Kind is the kind of content of a scanned QR code.
+type Kind int
+
+const (
+	KindUnknown   Kind = iota
+	KindQrData         // activation QR code of a bank, a QrData json
+	KindOtpAuth        // otpauth:// url of an authenticator app
+	KindMigration      // otpauth-migration:// url of a Google Authenticator export
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindQrData:
+		return "activation"
+	case KindOtpAuth:
+		return "otpauth"
+	case KindMigration:
+		return "migration"
+	}
+	return "unknown"
+}
+
+var (
+	ErrUnknownPayload         = errors.New("QR code is not an activation code or an otpauth url")
+	ErrInvalidPayload         = errors.New("invalid activation QR code")
+	ErrExpired                = errors.New("activation QR code is expired")
+	ErrVerificationCodeLength = errors.New("wrong verification code length")
+	ErrPinLength              = errors.New("wrong pin length")
+)
+
+// Payload is the parsed content of a scanned QR code. QrData is set for
+// activation codes of banks and Keys for otpauth and migration urls.
+type Payload struct {
+	Kind   Kind
+	Text   string
+	QrData *QrData
+	Keys   []*otpauth.Key
+}
+
+// Detect returns the kind of a scanned payload without validating it.
+func Detect(text string) Kind {
+	text = strings.TrimSpace(text)
+
+	if strings.HasPrefix(text, "{") {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal([]byte(text), &fields) == nil {
+			if _, ok := fields["token"]; ok {
+				return KindQrData
+			}
+		}
+		return KindUnknown
+	}
+
+	u, err := url.Parse(text)
+	if err != nil {
+		return KindUnknown
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "otpauth":
+		return KindOtpAuth
+	case "otpauth-migration":
+		return KindMigration
+	}
+	return KindUnknown
+}
+
+// ParsePayload detects and decodes a scanned payload.
+func ParsePayload(text string) (*Payload, error) {
+	text = strings.TrimSpace(text)
+	payload := &Payload{Kind: Detect(text), Text: text}
+
+	switch payload.Kind {
+	case KindQrData:
+		var qrData QrData
+		if err := json.Unmarshal([]byte(text), &qrData); err != nil {
+			return nil, fmt.Errorf("%w : %v", ErrInvalidPayload, err)
+		}
+		if len(qrData.Token) == 0 || len(qrData.Cif) == 0 {
+			return nil, fmt.Errorf("%w : token or cif is missing", ErrInvalidPayload)
+		}
+		payload.QrData = &qrData
+
+	case KindOtpAuth:
+		key, err := otpauth.Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		payload.Keys = []*otpauth.Key{key}
+
+	case KindMigration:
+		migration, err := otpauth.ParseMigration(text)
+		if err != nil {
+			return nil, err
+		}
+		payload.Keys = migration.Keys
+
+	default:
+		return nil, ErrUnknownPayload
+	}
+
+	return payload, nil
+}
+
+// FromImage returns the payloads of every QR code in the image that is an
+// activation code or an otpauth url, from top to bottom.
+func FromImage(img image.Image) ([]*Payload, error) {
+	results, err := qr.DecodeAll(img, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var payloads []*Payload
+	for _, result := range results {
+		payload, err := ParsePayload(result.Text)
+		if errors.Is(err, ErrUnknownPayload) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, payload)
+	}
+
+	if len(payloads) == 0 {
+		return nil, ErrUnknownPayload
+	}
+	return payloads, nil
+}
+
+// Expiry returns when the activation code expires, or the zero time if it
+// does not say.
+func (p *Payload) Expiry() time.Time {
+	if p.QrData == nil || p.QrData.TokenTimeToLiveSeconds <= 0 {
+		return time.Time{}
+	}
+
+	generated := time.Unix(0, p.QrData.TokenGeneratedTime*int64(time.Millisecond))
+	return generated.Add(time.Duration(p.QrData.TokenTimeToLiveSeconds) * time.Second)
+}
+
+// Validate checks that the activation code is not expired at now and that
+// the verification code and pin have the lengths it asks for. Payloads of
+// authenticator apps are always valid.
+func (p *Payload) Validate(now time.Time, verificationCode, pin string) error {
+	if p.QrData == nil {
+		return nil
+	}
+
+	if expiry := p.Expiry(); !expiry.IsZero() && now.After(expiry) {
+		return fmt.Errorf("%w : it expired %s ago, ask the bank for a new one", ErrExpired, now.Sub(expiry).Round(time.Second))
+	}
+
+	if err := checkLength(ErrVerificationCodeLength, "verification code", verificationCode, p.QrData.VerificationCodeLength); err != nil {
+		return err
+	}
+
+	return checkLength(ErrPinLength, "pin", pin, p.QrData.PinLength)
+}
+
+func checkLength(lengthErr error, name, value string, length int) error {
+	if length <= 0 {
+		return nil
+	}
+
+	if len(value) != length {
+		return fmt.Errorf("%w : %s should be %d digits, got %d", lengthErr, name, length, len(value))
+	}
+
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("%w : %s should only have digits", lengthErr, name)
+		}
+	}
+	return nil
+}
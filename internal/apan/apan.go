@@ -232,6 +232,7 @@ func (a *Apan) Activate(token, cif, pin, verificationCode string) (*tokenObject.
 		BankName:     "Ansar",
 		AccountId:    cif,
 		Seed:         result.SecretKey,
+		ServerHost:   "svccard.ansarbank.ir",
 	}, err
 }
 
@@ -0,0 +1,61 @@
+package apan
+
+import (
+	"otp/internal/provider"
+	tokenObject "otp/internal/token"
+)
+
+const fieldLoginCode = "loginCode"
+
+type apanProvider struct{}
+
+func init() {
+	provider.Register(apanProvider{})
+}
+
+func (apanProvider) ID() string {
+	return "apan"
+}
+
+func (apanProvider) Name() string {
+	return "Apan"
+}
+
+func (apanProvider) NewSession(provider.Options) (provider.Session, error) {
+	var api *Apan
+
+	return provider.NewFlow(
+		provider.Step{
+			Fields: []provider.Field{
+				{Name: provider.FieldMobileNumber, Description: "Mobile number registered in the bank"},
+			},
+			Run: func(values map[string]string) (*tokenObject.Token, error) {
+				var err error
+				api, err = New(values[provider.FieldMobileNumber])
+				if err != nil {
+					return nil, err
+				}
+				return nil, api.GetLoginCode()
+			},
+		},
+		provider.Step{
+			Fields: []provider.Field{
+				{Name: fieldLoginCode, Description: "Login code sent by sms"},
+			},
+			Run: func(values map[string]string) (*tokenObject.Token, error) {
+				return nil, api.LoginWithCode(values[fieldLoginCode])
+			},
+		},
+		provider.Step{
+			Fields: []provider.Field{
+				{Name: provider.FieldToken, Description: "Token in the activation QR code"},
+				{Name: provider.FieldCif, Description: "Customer number in the activation QR code"},
+				{Name: provider.FieldVerificationCode, Description: "Verification code sent by sms"},
+				{Name: provider.FieldPin, Description: "Arbitrary pin to protect the token", Secret: true},
+			},
+			Run: func(values map[string]string) (*tokenObject.Token, error) {
+				return api.Activate(values[provider.FieldToken], values[provider.FieldCif], values[provider.FieldPin], values[provider.FieldVerificationCode])
+			},
+		},
+	), nil
+}
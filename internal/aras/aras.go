@@ -54,6 +54,7 @@ func Activate(bank Bank, token, verificationCode, pin, cif string, generateFirst
 	}
 
 	responseToken.Seed = string(secret) + pin
+	responseToken.ServerHost = Host(bank.URL)
 	return responseToken, nil
 }
 
@@ -0,0 +1,92 @@
+package aras
+
+import (
+	"encoding/json"
+	"log"
+	. "otp/internal/structs"
+)
+
+// Banks lists the banks served by the Aras OTP platform.
+var Banks []Bank
+
+var banksData = []byte(`[
+  {
+    "id": "101",
+    "name": "Dey Bank",
+    "phone": "02128930",
+    "url": "https://otp.bdi24.com/yaghut/rest/deyOtp/card/activateOtpToken/",
+    "publicKey": "A5gYv/W7bZ8p0DYscSPvnE1Nr47/N47+k9Ex4Q0GsVlq",
+    "certificates": [
+      "sha256/De26Cax4g2OrKcs8H5A8ZRVQuHZVHckr1pY64iyYgQQ=",
+      "sha256/S4AbJNGvyS57nzJwv8sPMUML8VHSqH1vbiBftdPcErI=",
+      "sha256/qiYwp7YXsE0KKUureoyqpQFubb5gSDeoOoVxn6tmfrU="
+    ]
+  },
+  {
+    "id": "102",
+    "name": "Hekmat Iranian Bank",
+    "phone": "02166455876",
+    "url": "https://otp.hibank24.ir/yaghut/rest/hekmat/card/activateOtpToken/",
+    "publicKey": "AywEUt2KdxmOSXJqnAX9/pqanJTHOMAW55FfX9EqsH/0",
+    "certificates": [
+      "sha256/ROMyy0Hs5SMXGBVn+fBjDMx7K4ovjcCAp7/SLJ3rJvc=",
+      "sha256/S4AbJNGvyS57nzJwv8sPMUML8VHSqH1vbiBftdPcErI=",
+      "sha256/qiYwp7YXsE0KKUureoyqpQFubb5gSDeoOoVxn6tmfrU="
+    ]
+  },
+  {
+    "id": "103",
+    "name": "Sina Bank",
+    "phone": "02141731",
+    "url": "https://otp.sina24h.com/sina/rest/sinagss/card/activateOtpToken/",
+    "publicKey": "AzeNaz8WLNMuhvcqh2Yw8ode2YcECc+2odGdjTfhx1G7",
+    "certificates": [
+      "sha256/gb9iRV1ZqM9nRNU1QS8dBV9bH/ybStSBJRi0i6Edyqg=",
+      "sha256/klO23nT2ehFDXCfx3eHTDRESMz3asj1muO+4aIdjiuY=",
+      "sha256/grX4Ta9HpZx6tSHkmCrvpApTQGo67CYDnvprLg5yRME="
+    ]
+  },
+  {
+    "id": "104",
+    "name": "Eghtesade Novin Bank",
+    "phone": "02148031000",
+    "url": "https://otp.enbank.ir/yaghut/rest/enGSS/card/activateOtpToken/",
+    "publicKey": "AjKuAoul8hkDP9450u+Iqo5bS/rXMcdHR1PJv0vSiGO0",
+    "certificates": [
+      "sha256/LaaFM7i/ZWnQ0V0KwGjr12k4JRuvWmPpZbz501G2jgY=",
+      "sha256/S4AbJNGvyS57nzJwv8sPMUML8VHSqH1vbiBftdPcErI=",
+      "sha256/qiYwp7YXsE0KKUureoyqpQFubb5gSDeoOoVxn6tmfrU="
+    ]
+  },
+  {
+    "id": "105",
+    "name": "Ansar Bank",
+    "phone": "096300",
+    "url": "https://otp.ansarbank.com/yaghut/rest/ansarGSS/card/activateOtpToken/",
+    "publicKey": "A561Ta3+gxYcQzd74CNI7hn8p25Dd1N9qyGGuS7oGx0D",
+    "certificates": [
+      "sha256/Ul6Pd1pAPwEFxip4RJglrY5mCr1LOLQk4gWucKTIEXg=",
+      "sha256/S4AbJNGvyS57nzJwv8sPMUML8VHSqH1vbiBftdPcErI=",
+      "sha256/qiYwp7YXsE0KKUureoyqpQFubb5gSDeoOoVxn6tmfrU="
+    ]
+  },
+  {
+    "id": "106",
+    "name": "Mehr Bank",
+    "phone": "0214322",
+    "url": "https://otp.qmb.ir/gharz/rest/gharzmehrOTP/card/activateOtpToken/",
+    "publicKey": "Ap7ANr4+XKw2k98ys9mcYFS69k4/UBwJbQXvoY11OU5M",
+    "certificates": [
+      "sha256/wD9yFLVnbZQQiFS2LLJcICOW+hZ9he8SRnKryVJqWsM=",
+      "sha256/S4AbJNGvyS57nzJwv8sPMUML8VHSqH1vbiBftdPcErI=",
+      "sha256/qiYwp7YXsE0KKUureoyqpQFubb5gSDeoOoVxn6tmfrU="
+    ]
+  }
+]`)
+
+func init() {
+	err := json.Unmarshal(banksData, &Banks)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,63 @@
+package aras
+
+import (
+	"otp/internal/provider"
+	. "otp/internal/structs"
+	"otp/internal/token"
+)
+
+type arasProvider struct {
+	bank Bank
+}
+
+func init() {
+	for _, bank := range Banks {
+		provider.Register(&arasProvider{bank: bank})
+	}
+}
+
+func (p *arasProvider) ID() string {
+	return p.bank.ID
+}
+
+func (p *arasProvider) Name() string {
+	return p.bank.Name
+}
+
+func (p *arasProvider) NewSession(options provider.Options) (provider.Session, error) {
+	return provider.NewFlow(provider.Step{
+		Fields: []provider.Field{
+			{Name: provider.FieldToken, Description: "Token in the activation QR code"},
+			{Name: provider.FieldCif, Description: "Customer number in the activation QR code"},
+			{Name: provider.FieldVerificationCode, Description: "Verification code sent by sms"},
+			{Name: provider.FieldPin, Description: "Arbitrary pin to protect the token", Secret: true},
+			{Name: provider.FieldServiceChannelOtpType, Description: "CARD_FIRST_PASSWORD or CARD_SECOND_PASSWORD, both if empty", Optional: true},
+		},
+		Run: func(values map[string]string) (*token.Token, error) {
+			return p.activate(values, options)
+		},
+	}), nil
+}
+
+func (p *arasProvider) activate(values map[string]string, options provider.Options) (*token.Token, error) {
+	generateFirstOtp, generateSecondOtp := true, true
+	switch values[provider.FieldServiceChannelOtpType] {
+	case "CARD_FIRST_PASSWORD":
+		generateSecondOtp = false
+	case "CARD_SECOND_PASSWORD":
+		generateFirstOtp = false
+	}
+
+	t, err := Activate(p.bank, values[provider.FieldToken], values[provider.FieldVerificationCode], values[provider.FieldPin], values[provider.FieldCif], generateFirstOtp, generateSecondOtp)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := options.Protect(t, values[provider.FieldPin]); err != nil {
+		return nil, err
+	}
+
+	t.BankName = p.bank.Name
+	t.AccountId = values[provider.FieldCif]
+	return t, nil
+}
@@ -0,0 +1,57 @@
+package clock
+
+import "time"
+
+// Clock tells the current time. Tokens and bank APIs use it instead of
+// time.Now, so a wrong system clock can be corrected and tests can freeze time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// System is the clock of the machine.
+var System Clock = systemClock{}
+
+type offsetClock struct {
+	base   Clock
+	offset time.Duration
+}
+
+func (c offsetClock) Now() time.Time {
+	return c.base.Now().Add(c.offset)
+}
+
+// Offset returns a clock that is offset ahead of the system clock.
+// A negative offset makes it behind.
+func Offset(offset time.Duration) Clock {
+	return WithOffset(System, offset)
+}
+
+// WithOffset returns a clock that is offset ahead of base.
+func WithOffset(base Clock, offset time.Duration) Clock {
+	return offsetClock{base: base, offset: offset}
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// Fixed returns a clock that is stopped at t.
+func Fixed(t time.Time) Clock {
+	return fixedClock(t)
+}
+
+// Now returns the time of c, or of the system clock if c is nil.
+func Now(c Clock) time.Time {
+	if c == nil {
+		return System.Now()
+	}
+	return c.Now()
+}
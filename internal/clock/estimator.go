@@ -0,0 +1,128 @@
+package clock
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sample is a single measurement of the offset between a server clock and
+// the system clock.
+type Sample struct {
+	Offset      time.Duration
+	Uncertainty time.Duration
+	At          time.Time
+}
+
+// Estimator keeps the recent clock offset samples of each host and estimates
+// the offset from the most precise one.
+type Estimator struct {
+	// MaxSamples is the number of samples kept for each host.
+	MaxSamples int
+
+	// MaxAge is how long a sample is used after it is taken.
+	MaxAge time.Duration
+
+	mu      sync.RWMutex
+	samples map[string][]Sample
+}
+
+func NewEstimator() *Estimator {
+	return &Estimator{
+		MaxSamples: 8,
+		MaxAge:     24 * time.Hour,
+		samples:    map[string][]Sample{},
+	}
+}
+
+// Default is the estimator fed by every bank request.
+var Default = NewEstimator()
+
+// Observe records that host reported serverTime in a response to a request
+// sent and received at the given system times. resolution is the precision
+// of serverTime, like time.Second for http Date headers.
+func (e *Estimator) Observe(host string, serverTime time.Time, resolution time.Duration, sent, received time.Time) {
+	if len(host) == 0 || serverTime.IsZero() || received.Before(sent) {
+		return
+	}
+
+	roundTrip := received.Sub(sent)
+	// The server time is truncated to its resolution and was read somewhere
+	// between sending and receiving, so both middles are the best guess.
+	middle := sent.Add(roundTrip / 2)
+	sample := Sample{
+		Offset:      serverTime.Add(resolution / 2).Sub(middle),
+		Uncertainty: roundTrip/2 + resolution/2,
+		At:          received,
+	}
+
+	host = strings.ToLower(host)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	samples := append(e.samples[host], sample)
+	if len(samples) > e.MaxSamples {
+		samples = samples[len(samples)-e.MaxSamples:]
+	}
+	e.samples[host] = samples
+}
+
+// Offset returns the estimated offset of the host clock from the system
+// clock, and whether there is any recent sample for the host.
+func (e *Estimator) Offset(host string) (time.Duration, bool) {
+	sample, ok := e.Estimate(host)
+	return sample.Offset, ok
+}
+
+// Estimate returns the most precise recent sample of the host.
+func (e *Estimator) Estimate(host string) (Sample, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := System.Now()
+	var best Sample
+	found := false
+	for _, sample := range e.samples[strings.ToLower(host)] {
+		if e.MaxAge > 0 && now.Sub(sample.At) > e.MaxAge {
+			continue
+		}
+		if !found || sample.Uncertainty < best.Uncertainty {
+			best = sample
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+type hostClock struct {
+	estimator *Estimator
+	host      string
+}
+
+func (c hostClock) Now() time.Time {
+	offset, _ := c.estimator.Offset(c.host)
+	return System.Now().Add(offset)
+}
+
+// ForHost returns a clock that follows the estimated clock of host. It is
+// the system clock until there is a sample for the host.
+func (e *Estimator) ForHost(host string) Clock {
+	return hostClock{estimator: e, host: host}
+}
+
+// Observe records a server time in the default estimator.
+func Observe(host string, serverTime time.Time, resolution time.Duration, sent, received time.Time) {
+	Default.Observe(host, serverTime, resolution, sent, received)
+}
+
+// HostOffset returns the estimated offset of host in the default estimator.
+func HostOffset(host string) (time.Duration, bool) {
+	return Default.Offset(host)
+}
+
+// ForHost returns a clock that follows host in the default estimator.
+func ForHost(host string) Clock {
+	return Default.ForHost(host)
+}
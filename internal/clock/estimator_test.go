@@ -0,0 +1,39 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimator(t *testing.T) {
+	e := NewEstimator()
+
+	if _, ok := e.Offset("bank.ir"); ok {
+		t.Fatal("offset of an unknown host")
+	}
+
+	sent := time.Now()
+	received := sent.Add(200 * time.Millisecond)
+
+	// Date header, one second resolution
+	e.Observe("Bank.ir", sent.Add(90*time.Second).Truncate(time.Second), time.Second, sent, received)
+	// Millisecond timestamp, 3 seconds ahead
+	e.Observe("bank.ir", sent.Add(100*time.Millisecond+3*time.Second), time.Millisecond, sent, received)
+
+	sample, ok := e.Estimate("bank.ir")
+	if !ok {
+		t.Fatal("no estimate")
+	}
+
+	if sample.Uncertainty != 100*time.Millisecond+500*time.Microsecond {
+		t.Fatalf("the most precise sample is not used : %v", sample.Uncertainty)
+	}
+
+	if d := sample.Offset - 3*time.Second; d < 0 || d > time.Millisecond {
+		t.Fatalf("expected 3s offset, got %v", sample.Offset)
+	}
+
+	if d := e.ForHost("bank.ir").Now().Sub(System.Now()) - 3*time.Second; d < -time.Second || d > time.Second {
+		t.Fatalf("host clock is off by %v", d)
+	}
+}
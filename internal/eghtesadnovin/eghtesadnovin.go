@@ -227,6 +227,7 @@ func (e *EghtesadNovin) activateToken(cif string, otpType OtpType, publicKey str
 		TimeInterval:    resp.OtpGenerationPeriod,
 		BankName:        "Eghtesad Novin",
 		AccountId:       cif,
+		ServerHost:      Host(e.baseURL),
 	}, nil
 }
 
@@ -0,0 +1,117 @@
+package eghtesadnovin
+
+import (
+	"otp/internal/provider"
+	. "otp/internal/structs"
+	"otp/internal/token"
+)
+
+const (
+	fieldDeviceId = "deviceId"
+	fieldUsername = "username"
+	fieldPassword = "password"
+	fieldTicket   = "ticket"
+)
+
+type eghtesadNovinProvider struct{}
+
+func init() {
+	provider.Register(eghtesadNovinProvider{})
+}
+
+func (eghtesadNovinProvider) ID() string {
+	return "eghtesadnovin"
+}
+
+func (eghtesadNovinProvider) Name() string {
+	return "Eghtesad Novin"
+}
+
+func (eghtesadNovinProvider) NewSession(provider.Options) (provider.Session, error) {
+	var (
+		api         *EghtesadNovin
+		otpType     OtpType
+		needsTicket bool
+		config      *EghtesadNovinOtpConfig
+	)
+
+	return provider.NewFlow(
+		provider.Step{
+			Fields: []provider.Field{
+				{Name: fieldUsername, Description: "Internet bank username"},
+				{Name: fieldPassword, Description: "Internet bank password", Secret: true},
+				{Name: provider.FieldServiceChannelOtpType, Description: "CARD_FIRST_PASSWORD, CARD_SECOND_PASSWORD or MODERN_FIRST_PASSWORD, CARD_SECOND_PASSWORD if empty", Optional: true},
+				{Name: fieldDeviceId, Description: "Android id of the device", Optional: true},
+			},
+			Run: func(values map[string]string) (*token.Token, error) {
+				otpType = CardSecondPassword
+				if len(values[provider.FieldServiceChannelOtpType]) > 0 {
+					otpType = OtpType(values[provider.FieldServiceChannelOtpType])
+				}
+
+				deviceId := values[fieldDeviceId]
+				if len(deviceId) == 0 {
+					deviceId = "7831C1D6BABA0000"
+				}
+				api = New(deviceId)
+
+				err := api.NewSession()
+				if err != nil {
+					return nil, err
+				}
+
+				resp, err := api.SignIn(values[fieldUsername], values[fieldPassword])
+				if err != nil {
+					return nil, err
+				}
+
+				for _, constraint := range resp.Constraints {
+					if constraint == "ticket" {
+						needsTicket = true
+					}
+				}
+
+				if !needsTicket {
+					config, err = api.GenerateToken(otpType)
+				}
+				return nil, err
+			},
+		},
+		provider.Step{
+			Fields: []provider.Field{
+				{Name: fieldTicket, Description: "Login code sent by sms"},
+			},
+			Skip: func() bool {
+				return !needsTicket
+			},
+			Run: func(values map[string]string) (*token.Token, error) {
+				err := api.SignInWithPin(values[fieldTicket])
+				if err != nil {
+					return nil, err
+				}
+
+				config, err = api.GenerateToken(otpType)
+				return nil, err
+			},
+		},
+		provider.Step{
+			Fields: []provider.Field{
+				{Name: provider.FieldVerificationCode, Description: "Verification code sent by sms"},
+			},
+			Run: func(values map[string]string) (*token.Token, error) {
+				t, err := api.Activate(config.Token, values[provider.FieldVerificationCode], config.Cif, otpType)
+				if err != nil {
+					return nil, err
+				}
+
+				if err := provider.BindChannel(t, otpType.String()); err != nil {
+					return nil, err
+				}
+
+				// The token is already activated, a failed logout should not lose it
+				_ = api.Logout()
+				return t, nil
+			},
+		},
+	), nil
+}
@@ -112,7 +112,6 @@ func (a *AES) Decrypt(data, iv []byte) ([]byte, error) {
 	default:
 		return nil, ErrNotImplemented
 	}
-	return result, nil
 }
 
 func (a *AES) pkcs7Pad(b []byte, blockSize int) ([]byte, error) {
@@ -0,0 +1,331 @@
+package interop
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"otp/pkg/otpauth"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Aegis also has raw and biometric slots, only password slots can be
+// opened here
+const aegisPasswordSlot = 1
+
+// scrypt parameters of Aegis password slots
+const (
+	aegisScryptN = 1 << 15
+	aegisScryptR = 8
+	aegisScryptP = 1
+)
+
+type aegisBackup struct {
+	Version int             `json:"version"`
+	Header  aegisHeader     `json:"header"`
+	Db      json.RawMessage `json:"db"`
+}
+
+type aegisHeader struct {
+	Slots  []aegisSlot  `json:"slots"`
+	Params *aegisParams `json:"params"`
+}
+
+type aegisSlot struct {
+	Type      int         `json:"type"`
+	UUID      string      `json:"uuid"`
+	Key       string      `json:"key"`
+	KeyParams aegisParams `json:"key_params"`
+	N         int         `json:"n,omitempty"`
+	R         int         `json:"r,omitempty"`
+	P         int         `json:"p,omitempty"`
+	Salt      string      `json:"salt,omitempty"`
+	Repaired  bool        `json:"repaired,omitempty"`
+}
+
+type aegisParams struct {
+	Nonce string `json:"nonce"`
+	Tag   string `json:"tag"`
+}
+
+type aegisDb struct {
+	Version int          `json:"version"`
+	Entries []aegisEntry `json:"entries"`
+}
+
+type aegisEntry struct {
+	Type   string    `json:"type"`
+	UUID   string    `json:"uuid"`
+	Name   string    `json:"name"`
+	Issuer string    `json:"issuer"`
+	Note   string    `json:"note"`
+	Icon   *string   `json:"icon"`
+	Info   aegisInfo `json:"info"`
+}
+
+type aegisInfo struct {
+	Secret  string `json:"secret"`
+	Algo    string `json:"algo"`
+	Digits  int    `json:"digits"`
+	Period  int    `json:"period,omitempty"`
+	Counter uint64 `json:"counter,omitempty"`
+}
+
+func readAegis(data []byte, password string) ([]*otpauth.Key, []Skipped, error) {
+	var backup aegisBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, nil, err
+	}
+
+	dbData := []byte(backup.Db)
+	if backup.Header.Params != nil {
+		if len(password) == 0 {
+			return nil, nil, ErrPasswordRequired
+		}
+
+		var err error
+		dbData, err = decryptAegis(&backup, password)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var db aegisDb
+	if err := json.Unmarshal(dbData, &db); err != nil {
+		return nil, nil, err
+	}
+
+	var keys []*otpauth.Key
+	var skipped []Skipped
+	for _, entry := range db.Entries {
+		entryType, err := parseType(entry.Type)
+		if err != nil {
+			skipped = append(skipped, Skipped{Name: entry.Name, Err: fmt.Errorf("%w : %s", ErrUnsupportedEntry, entry.Type)})
+			continue
+		}
+
+		secret, err := otpauth.DecodeSecret(entry.Info.Secret)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		algorithm, err := parseAlgorithm(entry.Info.Algo)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, err := newKey(&otpauth.Key{
+			Type:        entryType,
+			Issuer:      entry.Issuer,
+			AccountName: entry.Name,
+			Secret:      secret,
+			Algorithm:   algorithm,
+			Digits:      entry.Info.Digits,
+			Period:      entry.Info.Period,
+			Counter:     entry.Info.Counter,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, skipped, nil
+}
+
+func writeAegis(keys []*otpauth.Key, password string) ([]byte, error) {
+	db := aegisDb{Version: 2, Entries: []aegisEntry{}}
+	for _, key := range keys {
+		entry := aegisEntry{
+			Type:   key.Type,
+			UUID:   uuid.New().String(),
+			Name:   key.AccountName,
+			Issuer: key.Issuer,
+			Info: aegisInfo{
+				Secret: otpauth.EncodeSecret(key.Secret),
+				Algo:   key.Algorithm.String(),
+				Digits: key.Digits,
+			},
+		}
+
+		if key.Type == otpauth.TypeHotp {
+			entry.Info.Counter = key.Counter
+		} else {
+			entry.Info.Period = key.Period
+		}
+		db.Entries = append(db.Entries, entry)
+	}
+
+	dbData, err := json.Marshal(db)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := aegisBackup{Version: 1, Db: dbData}
+	if len(password) > 0 {
+		if err := encryptAegis(&backup, password); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.MarshalIndent(backup, "", "    ")
+}
+
+// decryptAegis opens the master key with the first password slot that
+// accepts the password and returns the decrypted db.
+func decryptAegis(backup *aegisBackup, password string) ([]byte, error) {
+	var encoded string
+	if err := json.Unmarshal(backup.Db, &encoded); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, slot := range backup.Header.Slots {
+		if slot.Type != aegisPasswordSlot {
+			continue
+		}
+
+		salt, err := hex.DecodeString(slot.Salt)
+		if err != nil {
+			return nil, err
+		}
+
+		derivedKey, err := scrypt.Key([]byte(password), salt, slot.N, slot.R, slot.P, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		encryptedKey, err := hex.DecodeString(slot.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		masterKey, err := openAegis(derivedKey, encryptedKey, &slot.KeyParams)
+		if err != nil {
+			continue
+		}
+
+		return openAegis(masterKey, ciphertext, backup.Header.Params)
+	}
+
+	return nil, ErrWrongPassword
+}
+
+// encryptAegis encrypts the db of the backup with a new master key, which is
+// stored in a single password slot.
+func encryptAegis(backup *aegisBackup, password string) error {
+	masterKey := make([]byte, 32)
+	salt := make([]byte, 32)
+	if _, err := rand.Read(masterKey); err != nil {
+		return err
+	}
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	derivedKey, err := scrypt.Key([]byte(password), salt, aegisScryptN, aegisScryptR, aegisScryptP, 32)
+	if err != nil {
+		return err
+	}
+
+	encryptedKey, keyParams, err := sealAegis(derivedKey, masterKey)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, params, err := sealAegis(masterKey, backup.Db)
+	if err != nil {
+		return err
+	}
+
+	db, err := json.Marshal(base64.StdEncoding.EncodeToString(ciphertext))
+	if err != nil {
+		return err
+	}
+
+	backup.Db = db
+	backup.Header = aegisHeader{
+		Slots: []aegisSlot{{
+			Type:      aegisPasswordSlot,
+			UUID:      uuid.New().String(),
+			Key:       hex.EncodeToString(encryptedKey),
+			KeyParams: *keyParams,
+			N:         aegisScryptN,
+			R:         aegisScryptR,
+			P:         aegisScryptP,
+			Salt:      hex.EncodeToString(salt),
+			Repaired:  true,
+		}},
+		Params: params,
+	}
+
+	return nil
+}
+
+// openAegis decrypts ciphertext with AES-GCM. Aegis stores the tag apart
+// from the ciphertext.
+func openAegis(key, ciphertext []byte, params *aegisParams) ([]byte, error) {
+	nonce, err := hex.DecodeString(params.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := hex.DecodeString(params.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAegisCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w : nonce size %d", ErrWrongPassword, len(nonce))
+	}
+
+	plaintext, err := aead.Open(nil, nonce, append(ciphertext, tag...), nil)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return plaintext, nil
+}
+
+// sealAegis encrypts plaintext with AES-GCM and a random nonce, and returns
+// the ciphertext without its tag.
+func sealAegis(key, plaintext []byte) ([]byte, *aegisParams, error) {
+	aead, err := newAegisCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+
+	sealed := aead.Seal(nil, nonce, plaintext, nil)
+	split := len(sealed) - aead.Overhead()
+
+	return sealed[:split], &aegisParams{
+		Nonce: hex.EncodeToString(nonce),
+		Tag:   hex.EncodeToString(sealed[split:]),
+	}, nil
+}
+
+func newAegisCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
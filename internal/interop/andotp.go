@@ -0,0 +1,96 @@
+package interop
+
+import (
+	"encoding/json"
+	"fmt"
+	"otp/pkg/otpauth"
+	"strings"
+)
+
+type andOTPEntry struct {
+	Secret    string   `json:"secret"`
+	Issuer    string   `json:"issuer"`
+	Label     string   `json:"label"`
+	Digits    int      `json:"digits"`
+	Type      string   `json:"type"`
+	Algorithm string   `json:"algorithm"`
+	Thumbnail string   `json:"thumbnail"`
+	Period    int      `json:"period,omitempty"`
+	Counter   uint64   `json:"counter,omitempty"`
+	Tags      []string `json:"tags"`
+}
+
+func readAndOTP(data []byte) ([]*otpauth.Key, []Skipped, error) {
+	var entries []andOTPEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, nil, err
+	}
+
+	var keys []*otpauth.Key
+	var skipped []Skipped
+	for _, entry := range entries {
+		entryType, err := parseType(entry.Type)
+		if err != nil {
+			skipped = append(skipped, Skipped{Name: entry.Label, Err: fmt.Errorf("%w : %s", ErrUnsupportedEntry, entry.Type)})
+			continue
+		}
+
+		secret, err := otpauth.DecodeSecret(entry.Secret)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		algorithm, err := parseAlgorithm(entry.Algorithm)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Old versions keep the issuer in the label
+		issuer, accountName := entry.Issuer, entry.Label
+		if parts := strings.SplitN(accountName, ":", 2); len(issuer) == 0 && len(parts) == 2 {
+			issuer, accountName = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		}
+
+		key, err := newKey(&otpauth.Key{
+			Type:        entryType,
+			Issuer:      issuer,
+			AccountName: accountName,
+			Secret:      secret,
+			Algorithm:   algorithm,
+			Digits:      entry.Digits,
+			Period:      entry.Period,
+			Counter:     entry.Counter,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, skipped, nil
+}
+
+func writeAndOTP(keys []*otpauth.Key) ([]byte, error) {
+	entries := []andOTPEntry{}
+	for _, key := range keys {
+		entry := andOTPEntry{
+			Secret:    otpauth.EncodeSecret(key.Secret),
+			Issuer:    key.Issuer,
+			Label:     key.AccountName,
+			Digits:    key.Digits,
+			Type:      strings.ToUpper(key.Type),
+			Algorithm: key.Algorithm.String(),
+			Thumbnail: "Default",
+			Tags:      []string{},
+		}
+
+		if key.Type == otpauth.TypeHotp {
+			entry.Counter = key.Counter
+		} else {
+			entry.Period = key.Period
+		}
+		entries = append(entries, entry)
+	}
+
+	return json.MarshalIndent(entries, "", "  ")
+}
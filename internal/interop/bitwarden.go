@@ -0,0 +1,119 @@
+package interop
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"otp/pkg/otpauth"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// bitwardenLoginItem is the item type of logins, the only items with totp
+const bitwardenLoginItem = 1
+
+type bitwardenBackup struct {
+	Encrypted bool            `json:"encrypted"`
+	Folders   []interface{}   `json:"folders"`
+	Items     []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	ID    string          `json:"id"`
+	Type  int             `json:"type"`
+	Name  string          `json:"name"`
+	Login *bitwardenLogin `json:"login,omitempty"`
+}
+
+type bitwardenLogin struct {
+	Username string `json:"username"`
+	Totp     string `json:"totp"`
+}
+
+func readBitwarden(data []byte) ([]*otpauth.Key, []Skipped, error) {
+	var backup bitwardenBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, nil, err
+	}
+
+	if backup.Encrypted {
+		return nil, nil, fmt.Errorf("%w : export bitwarden vaults as unencrypted json", ErrUnsupportedEncryption)
+	}
+
+	var keys []*otpauth.Key
+	var skipped []Skipped
+	for _, item := range backup.Items {
+		if item.Login == nil || len(item.Login.Totp) == 0 {
+			continue
+		}
+
+		key, err := parseTotpField(item.Login.Totp, item.Name, item.Login.Username)
+		if errors.Is(err, ErrUnsupportedEntry) {
+			skipped = append(skipped, Skipped{Name: item.Name, Err: err})
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s : %w", item.Name, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, skipped, nil
+}
+
+func writeBitwarden(keys []*otpauth.Key) ([]byte, error) {
+	backup := bitwardenBackup{
+		Folders: []interface{}{},
+		Items:   []bitwardenItem{},
+	}
+
+	for _, key := range keys {
+		u, err := key.URL()
+		if err != nil {
+			return nil, err
+		}
+
+		name := key.Issuer
+		if len(name) == 0 {
+			name = key.AccountName
+		}
+
+		backup.Items = append(backup.Items, bitwardenItem{
+			ID:   uuid.New().String(),
+			Type: bitwardenLoginItem,
+			Name: name,
+			Login: &bitwardenLogin{
+				Username: key.AccountName,
+				Totp:     u,
+			},
+		})
+	}
+
+	return json.MarshalIndent(backup, "", "  ")
+}
+
+// parseTotpField parses the totp field of password managers, which is
+// either an otpauth url or a bare base32 secret with the default parameters.
+func parseTotpField(value, issuer, accountName string) (*otpauth.Key, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(strings.ToLower(value), "otpauth:") {
+		return otpauth.Parse(value)
+	}
+
+	if strings.Contains(value, "://") {
+		return nil, fmt.Errorf("%w : %s", ErrUnsupportedEntry, value[:strings.Index(value, "://")])
+	}
+
+	secret, err := otpauth.DecodeSecret(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return newKey(&otpauth.Key{
+		Type:        otpauth.TypeTotp,
+		Issuer:      issuer,
+		AccountName: accountName,
+		Secret:      secret,
+	})
+}
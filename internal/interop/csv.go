@@ -0,0 +1,143 @@
+package interop
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"otp/pkg/otpauth"
+	"strconv"
+	"strings"
+)
+
+var csvHeader = []string{"issuer", "account", "secret", "type", "algorithm", "digits", "period", "counter"}
+
+// readCSV reads a csv file with the columns of csvHeader. A url column with
+// otpauth urls can be used instead, and only the secret column is required
+// otherwise.
+func readCSV(data []byte) ([]*otpauth.Key, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := columnIndexes(records[0])
+	_, hasURL := columns["url"]
+	_, hasSecret := columns["secret"]
+	if !hasURL && !hasSecret {
+		return nil, fmt.Errorf("%w : secret or url column is missing", ErrUnknownFormat)
+	}
+
+	var keys []*otpauth.Key
+	for line, record := range records[1:] {
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		key, err := readCSVRecord(field)
+		if err != nil {
+			return nil, fmt.Errorf("line %d : %w", line+2, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func readCSVRecord(field func(name string) string) (*otpauth.Key, error) {
+	if value := field("url"); len(value) > 0 {
+		return otpauth.Parse(value)
+	}
+
+	keyType, err := parseType(field("type"))
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := otpauth.DecodeSecret(field("secret"))
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, err := parseAlgorithm(field("algorithm"))
+	if err != nil {
+		return nil, err
+	}
+
+	key := &otpauth.Key{
+		Type:        keyType,
+		Issuer:      field("issuer"),
+		AccountName: field("account"),
+		Secret:      secret,
+		Algorithm:   algorithm,
+	}
+
+	if value := field("digits"); len(value) > 0 {
+		if key.Digits, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("%w : %s", otpauth.ErrInvalidDigits, value)
+		}
+	}
+
+	if value := field("period"); len(value) > 0 {
+		if key.Period, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("%w : %s", otpauth.ErrInvalidPeriod, value)
+		}
+	}
+
+	if value := field("counter"); len(value) > 0 {
+		if key.Counter, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w : %s", otpauth.ErrInvalidCounter, value)
+		}
+	}
+
+	return newKey(key)
+}
+
+func writeCSV(keys []*otpauth.Key) ([]byte, error) {
+	records := [][]string{csvHeader}
+	for _, key := range keys {
+		var period, counter string
+		if key.Type == otpauth.TypeHotp {
+			counter = strconv.FormatUint(key.Counter, 10)
+		} else {
+			period = strconv.Itoa(key.Period)
+		}
+
+		records = append(records, []string{
+			key.Issuer,
+			key.AccountName,
+			otpauth.EncodeSecret(key.Secret),
+			key.Type,
+			key.Algorithm.String(),
+			strconv.Itoa(key.Digits),
+			period,
+			counter,
+		})
+	}
+
+	return writeRecords(records)
+}
+
+// columnIndexes maps the lower case names of a csv header to their indexes.
+func columnIndexes(header []string) map[string]int {
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	return columns
+}
+
+func writeRecords(records [][]string) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,109 @@
+package interop
+
+import (
+	"encoding/json"
+	"fmt"
+	"otp/pkg/otpauth"
+	"strings"
+)
+
+type freeOTPBackup struct {
+	TokenOrder []string       `json:"tokenOrder"`
+	Tokens     []freeOTPToken `json:"tokens"`
+}
+
+// freeOTPToken is a token of a FreeOTP+ backup. The secret is a list of
+// signed bytes, as Java serializes byte arrays.
+type freeOTPToken struct {
+	Algo      string `json:"algo"`
+	Counter   uint64 `json:"counter"`
+	Digits    int    `json:"digits"`
+	IssuerExt string `json:"issuerExt"`
+	IssuerInt string `json:"issuerInt"`
+	Label     string `json:"label"`
+	Period    int    `json:"period"`
+	Secret    []int8 `json:"secret"`
+	Type      string `json:"type"`
+}
+
+func readFreeOTP(data []byte) ([]*otpauth.Key, []Skipped, error) {
+	var backup freeOTPBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, nil, err
+	}
+
+	var keys []*otpauth.Key
+	var skipped []Skipped
+	for _, t := range backup.Tokens {
+		entryType, err := parseType(t.Type)
+		if err != nil {
+			skipped = append(skipped, Skipped{Name: t.Label, Err: fmt.Errorf("%w : %s", ErrUnsupportedEntry, t.Type)})
+			continue
+		}
+
+		algorithm, err := parseAlgorithm(t.Algo)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		secret := make([]byte, len(t.Secret))
+		for i, b := range t.Secret {
+			secret[i] = byte(b)
+		}
+
+		issuer := t.IssuerExt
+		if len(issuer) == 0 {
+			issuer = t.IssuerInt
+		}
+
+		key, err := newKey(&otpauth.Key{
+			Type:        entryType,
+			Issuer:      issuer,
+			AccountName: t.Label,
+			Secret:      secret,
+			Algorithm:   algorithm,
+			Digits:      t.Digits,
+			Period:      t.Period,
+			Counter:     t.Counter,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, skipped, nil
+}
+
+func writeFreeOTP(keys []*otpauth.Key) ([]byte, error) {
+	backup := freeOTPBackup{
+		TokenOrder: []string{},
+		Tokens:     []freeOTPToken{},
+	}
+
+	for _, key := range keys {
+		secret := make([]int8, len(key.Secret))
+		for i, b := range key.Secret {
+			secret[i] = int8(b)
+		}
+
+		t := freeOTPToken{
+			Algo:      key.Algorithm.String(),
+			Digits:    key.Digits,
+			IssuerExt: key.Issuer,
+			IssuerInt: key.Issuer,
+			Label:     key.AccountName,
+			Period:    key.Period,
+			Secret:    secret,
+			Type:      strings.ToUpper(key.Type),
+		}
+		if key.Type == otpauth.TypeHotp {
+			t.Counter = key.Counter
+		}
+
+		backup.TokenOrder = append(backup.TokenOrder, key.Issuer+":"+key.AccountName)
+		backup.Tokens = append(backup.Tokens, t)
+	}
+
+	return json.Marshal(backup)
+}
@@ -0,0 +1,268 @@
+// Package interop reads and writes the backup formats of other
+// authenticator apps.
+//
+// All formats are converted to and from otpauth keys, and KeysFromTokens and
+// TokensFromKeys convert those to and from tokens.
+package interop
+
+import (
+	"errors"
+	"fmt"
+	"otp/internal/token"
+	"otp/pkg/otpauth"
+	"reflect"
+	"strings"
+)
+
+// Format is the name of a backup format.
+type Format string
+
+const (
+	Aegis      Format = "aegis"
+	AndOTP     Format = "andotp"
+	TwoFAS     Format = "2fas"
+	FreeOTP    Format = "freeotp+"
+	Bitwarden  Format = "bitwarden"
+	KeePassXC  Format = "keepassxc"
+	CSV        Format = "csv"
+	OtpAuthURL Format = "otpauth"
+)
+
+// Formats lists all supported formats.
+var Formats = []Format{Aegis, AndOTP, TwoFAS, FreeOTP, Bitwarden, KeePassXC, CSV, OtpAuthURL}
+
+var (
+	ErrUnknownFormat    = errors.New("unknown backup format")
+	ErrPasswordRequired = errors.New("backup is encrypted, a password is required")
+	ErrWrongPassword    = errors.New("wrong password or corrupted backup")
+	ErrUnsupportedEntry = errors.New("entry type is not supported")
+
+	ErrUnsupportedEncryption = errors.New("backup encryption is not supported")
+)
+
+// Skipped is an entry of a backup that is not imported, like the Steam
+// entries of Aegis.
+type Skipped struct {
+	Name string
+	Err  error
+}
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(string(format), strings.TrimSpace(name)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("%w : %s", ErrUnknownFormat, name)
+}
+
+// Import reads the keys of a backup. The password is only used by
+// encrypted Aegis backups. Entries of unsupported types are returned as
+// skipped instead of failing the whole import.
+func Import(format Format, data []byte, password string) ([]*otpauth.Key, []Skipped, error) {
+	switch format {
+	case Aegis:
+		return readAegis(data, password)
+	case AndOTP:
+		return readAndOTP(data)
+	case TwoFAS:
+		return readTwoFAS(data)
+	case FreeOTP:
+		return readFreeOTP(data)
+	case Bitwarden:
+		return readBitwarden(data)
+	case KeePassXC:
+		return readKeePassXC(data)
+	case CSV:
+		keys, err := readCSV(data)
+		return keys, nil, err
+	case OtpAuthURL:
+		keys, err := readOtpAuthURLs(data)
+		return keys, nil, err
+	}
+	return nil, nil, fmt.Errorf("%w : %s", ErrUnknownFormat, format)
+}
+
+// Export writes the keys as a backup. Aegis backups are encrypted with the
+// password if it is not empty.
+func Export(format Format, keys []*otpauth.Key, password string) ([]byte, error) {
+	for _, key := range keys {
+		if err := key.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch format {
+	case Aegis:
+		return writeAegis(keys, password)
+	case AndOTP:
+		return writeAndOTP(keys)
+	case TwoFAS:
+		return writeTwoFAS(keys)
+	case FreeOTP:
+		return writeFreeOTP(keys)
+	case Bitwarden:
+		return writeBitwarden(keys)
+	case KeePassXC:
+		return writeKeePassXC(keys)
+	case CSV:
+		return writeCSV(keys)
+	case OtpAuthURL:
+		return writeOtpAuthURLs(keys)
+	}
+	return nil, fmt.Errorf("%w : %s", ErrUnknownFormat, format)
+}
+
+// KeysFromTokens returns a key for every slot of the tokens. Slots with the
+// same key, as tokens imported from authenticator apps have, are returned
+// once. Keys of tokens with more than one distinct slot have the slot name
+// after their account name. Unless lossy is set, tokens that append zeros
+// to short codes return token.ErrNotPortable, see token.LossyKey.
+func KeysFromTokens(tokens []*token.Token, lossy bool) ([]*otpauth.Key, error) {
+	var keys []*otpauth.Key
+	for _, t := range tokens {
+		var tokenKeys []*otpauth.Key
+		var slots []token.Slot
+		for _, slot := range t.SlotNames() {
+			key, err := t.Key(slot)
+			if lossy && errors.Is(err, token.ErrNotPortable) {
+				key, err = t.LossyKey(slot)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s %s : %w", t.BankName, slot, err)
+			}
+
+			if !containsKey(tokenKeys, key) {
+				tokenKeys = append(tokenKeys, key)
+				slots = append(slots, slot)
+			}
+		}
+
+		if len(tokenKeys) > 1 {
+			for i, key := range tokenKeys {
+				key.AccountName = strings.TrimSpace(key.AccountName + " " + string(slots[i]))
+			}
+		}
+		keys = append(keys, tokenKeys...)
+	}
+	return keys, nil
+}
+
+func containsKey(keys []*otpauth.Key, key *otpauth.Key) bool {
+	for _, k := range keys {
+		if reflect.DeepEqual(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// TokensFromKeys returns a token for every key.
+func TokensFromKeys(keys []*otpauth.Key) []*token.Token {
+	var tokens []*token.Token
+	for _, key := range keys {
+		tokens = append(tokens, token.FromKey(key))
+	}
+	return tokens
+}
+
+// OtpAuths returns the keys as otpauth urls.
+func OtpAuths(keys []*otpauth.Key) ([]*otpauth.OtpAuth, error) {
+	var result []*otpauth.OtpAuth
+	for _, key := range keys {
+		u, err := key.URL()
+		if err != nil {
+			return nil, err
+		}
+
+		otpAuth, err := otpauth.NewKeyFromURL(u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, otpAuth)
+	}
+	return result, nil
+}
+
+// KeysFromOtpAuths validates otpauth urls and returns their keys.
+func KeysFromOtpAuths(otpAuths []*otpauth.OtpAuth) ([]*otpauth.Key, error) {
+	var keys []*otpauth.Key
+	for _, otpAuth := range otpAuths {
+		key, err := otpAuth.Key()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func readOtpAuthURLs(data []byte) ([]*otpauth.Key, error) {
+	var keys []*otpauth.Key
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		key, err := otpauth.Parse(line)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func writeOtpAuthURLs(keys []*otpauth.Key) ([]byte, error) {
+	var b strings.Builder
+	for _, key := range keys {
+		u, err := key.URL()
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(u)
+		b.WriteString("\n")
+	}
+	return []byte(b.String()), nil
+}
+
+// Helpers shared by the json formats
+
+func parseType(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", otpauth.TypeTotp:
+		return otpauth.TypeTotp, nil
+	case otpauth.TypeHotp:
+		return otpauth.TypeHotp, nil
+	}
+	return "", fmt.Errorf("%w : %s", otpauth.ErrInvalidType, name)
+}
+
+func parseAlgorithm(name string) (otpauth.Algorithm, error) {
+	if len(name) == 0 {
+		return otpauth.AlgorithmSHA1, nil
+	}
+
+	algorithm, err := otpauth.ParseAlgorithm(name)
+	if err != nil {
+		return 0, fmt.Errorf("%w : %s", otpauth.ErrInvalidAlgorithm, name)
+	}
+	return algorithm, nil
+}
+
+// newKey fills the defaults of a key read from a backup and validates it.
+func newKey(key *otpauth.Key) (*otpauth.Key, error) {
+	if key.Digits == 0 {
+		key.Digits = 6
+	}
+	if key.Period == 0 {
+		key.Period = 30
+	}
+
+	if err := key.Validate(); err != nil {
+		return nil, fmt.Errorf("%s %s : %w", key.Issuer, key.AccountName, err)
+	}
+	return key, nil
+}
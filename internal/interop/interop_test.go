@@ -0,0 +1,222 @@
+package interop
+
+import (
+	"bytes"
+	"errors"
+	"otp/internal/token"
+	"otp/pkg/otpauth"
+	"reflect"
+	"testing"
+)
+
+func testKeys() []*otpauth.Key {
+	return []*otpauth.Key{
+		{
+			Type:        otpauth.TypeTotp,
+			Issuer:      "RFC 6238",
+			AccountName: "alice@example.com",
+			Secret:      []byte("12345678901234567890"),
+			Algorithm:   otpauth.AlgorithmSHA256,
+			Digits:      8,
+			Period:      60,
+		},
+		{
+			Type:        otpauth.TypeHotp,
+			Issuer:      "Bank:Branch",
+			AccountName: "bob",
+			Secret:      []byte{0xff, 0x00, 0x80, 0x7f},
+			Algorithm:   otpauth.AlgorithmSHA1,
+			Digits:      6,
+			Period:      30,
+			Counter:     42,
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range Formats {
+		data, err := Export(format, testKeys(), "")
+		if err != nil {
+			t.Fatalf("%s : %v", format, err)
+		}
+
+		keys, _, err := Import(format, data, "")
+		if err != nil {
+			t.Fatalf("%s : %v\n%s", format, err, data)
+		}
+
+		if !reflect.DeepEqual(keys, testKeys()) {
+			t.Fatalf("%s : wrong keys %v", format, keys)
+		}
+	}
+}
+
+func TestEncryptedAegis(t *testing.T) {
+	data, err := Export(Aegis, testKeys(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(data, []byte("alice")) {
+		t.Fatal("db is not encrypted")
+	}
+
+	if _, _, err := Import(Aegis, data, ""); !errors.Is(err, ErrPasswordRequired) {
+		t.Fatalf("expected ErrPasswordRequired, got %v", err)
+	}
+
+	if _, _, err := Import(Aegis, data, "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected ErrWrongPassword, got %v", err)
+	}
+
+	keys, _, err := Import(Aegis, data, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(keys, testKeys()) {
+		t.Fatalf("wrong keys %v", keys)
+	}
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		format Format
+		data   string
+	}{
+		{AndOTP, `[{"secret":"gezdgnbvgy3tqojq","label":"RFC 6238:alice","digits":6,"type":"TOTP","algorithm":"SHA1","period":30}]`},
+		{TwoFAS, `{"services":[{"name":"RFC 6238","secret":"GEZDGNBVGY3TQOJQ","otp":{"account":"alice","digits":6,"period":30,"algorithm":"SHA1","tokenType":"TOTP"}}],"schemaVersion":4}`},
+		{FreeOTP, `{"tokens":[{"algo":"SHA1","digits":6,"issuerExt":"RFC 6238","label":"alice","period":30,"secret":[49,50,51,52,53,54,55,56,57,48],"type":"TOTP"}]}`},
+		{Bitwarden, `{"encrypted":false,"items":[{"type":2,"name":"note"},{"type":1,"name":"RFC 6238","login":{"username":"alice","totp":"GEZD GNBV GY3T QOJQ"}}]}`},
+		{KeePassXC, "\"Group\",\"Title\",\"Username\",\"TOTP\"\n\"Root\",\"RFC 6238\",\"alice\",\"otpauth://totp/RFC%206238:alice?secret=GEZDGNBVGY3TQOJQ\"\n\"Root\",\"mail\",\"alice\",\"\"\n"},
+		{CSV, "Issuer,Account,Secret\nRFC 6238,alice,GEZDGNBVGY3TQOJQ\n"},
+	}
+
+	expected := []*otpauth.Key{{
+		Type:        otpauth.TypeTotp,
+		Issuer:      "RFC 6238",
+		AccountName: "alice",
+		Secret:      []byte("1234567890"),
+		Algorithm:   otpauth.AlgorithmSHA1,
+		Digits:      6,
+		Period:      30,
+	}}
+
+	for _, test := range tests {
+		keys, _, err := Import(test.format, []byte(test.data), "")
+		if err != nil {
+			t.Fatalf("%s : %v", test.format, err)
+		}
+
+		if !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("%s : wrong keys %v", test.format, keys)
+		}
+	}
+
+}
+
+func TestImportSkipped(t *testing.T) {
+	tests := []struct {
+		format Format
+		data   string
+	}{
+		{Aegis, `{"version":1,"header":{},"db":{"entries":[{"type":"steam","name":"steam","info":{"secret":"GEZDGNBVGY3TQOJQ"}},{"type":"totp","name":"alice","info":{"secret":"GEZDGNBVGY3TQOJQ"}}]}}`},
+		{AndOTP, `[{"type":"STEAM","label":"steam","secret":"GEZDGNBVGY3TQOJQ"},{"type":"TOTP","label":"alice","secret":"GEZDGNBVGY3TQOJQ"}]`},
+		{FreeOTP, `{"tokens":[{"type":"STEAM","label":"steam","secret":[49]},{"type":"TOTP","label":"alice","secret":[49]}]}`},
+		{TwoFAS, `{"services":[{"name":"steam","secret":"GEZDGNBVGY3TQOJQ","otp":{"tokenType":"STEAM"}},{"name":"alice","secret":"GEZDGNBVGY3TQOJQ","otp":{"tokenType":"TOTP"}}]}`},
+		{Bitwarden, `{"encrypted":false,"items":[{"type":1,"name":"steam","login":{"totp":"steam://GEZDGNBVGY3TQOJQ"}},{"type":1,"name":"alice","login":{"totp":"GEZDGNBVGY3TQOJQ"}}]}`},
+		{KeePassXC, "\"Title\",\"Username\",\"TOTP\"\n\"steam\",\"\",\"steam://GEZDGNBVGY3TQOJQ\"\n\"alice\",\"\",\"GEZDGNBVGY3TQOJQ\"\n"},
+	}
+
+	for _, test := range tests {
+		keys, skipped, err := Import(test.format, []byte(test.data), "")
+		if err != nil {
+			t.Fatalf("%s : %v", test.format, err)
+		}
+
+		if len(keys) != 1 {
+			t.Fatalf("%s : expected a key, got %v", test.format, keys)
+		}
+
+		if len(skipped) != 1 || skipped[0].Name != "steam" || !errors.Is(skipped[0].Err, ErrUnsupportedEntry) {
+			t.Fatalf("%s : expected the steam entry to be skipped, got %v", test.format, skipped)
+		}
+	}
+}
+
+func TestKeePassXCAttributes(t *testing.T) {
+	key, err := KeyFromKeePassXCAttributes("RFC 6238", "alice", map[string]string{
+		TotpSeedAttribute:     "GEZDGNBVGY3TQOJQ",
+		TotpSettingsAttribute: "60;8",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Period != 60 || key.Digits != 8 || string(key.Secret) != "1234567890" {
+		t.Fatalf("wrong key %v", key)
+	}
+
+	attributes, err := KeePassXCAttributes(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attributes[TotpSettingsAttribute] != "60;8" || attributes[OtpAttribute] != key.String() {
+		t.Fatalf("wrong attributes %v", attributes)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	tokens := []*token.Token{{
+		FirstOtpLength:  6,
+		SecondOtpLength: 8,
+		TimeInterval:    30000,
+		BankName:        "Sina",
+		AccountId:       "6177236",
+		Seed:            "3132333435363738393031323334353637383930",
+	}}
+
+	// The codes of the bank token append zeros
+	if _, err := KeysFromTokens(tokens, false); !errors.Is(err, token.ErrNotPortable) {
+		t.Fatalf("expected ErrNotPortable, got %v", err)
+	}
+
+	keys, err := KeysFromTokens(tokens, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 2 || keys[0].AccountName != "6177236 pin1" || keys[1].Digits != 8 {
+		t.Fatalf("wrong keys %v", keys)
+	}
+
+	converted := TokensFromKeys(keys)
+	if converted[1].Seed != tokens[0].Seed || converted[1].FirstOtpLength != 8 {
+		t.Fatalf("wrong token %+v", converted[1])
+	}
+
+	// The slots of imported tokens are the same key
+	reconverted, err := KeysFromTokens(converted[:1], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reconverted) != 1 || reconverted[0].AccountName != keys[0].AccountName {
+		t.Fatalf("wrong keys %v", reconverted)
+	}
+
+	otpAuths, err := OtpAuths(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromOtpAuths, err := KeysFromOtpAuths(otpAuths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fromOtpAuths, keys) {
+		t.Fatalf("wrong keys %v", fromOtpAuths)
+	}
+}
@@ -0,0 +1,143 @@
+package interop
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"otp/pkg/otpauth"
+	"strconv"
+	"strings"
+)
+
+// Attributes of KeePassXC entries. Current versions store an otpauth url in
+// OtpAttribute, older ones and the KeeOtp plugin use the seed and settings
+// attributes.
+const (
+	OtpAttribute          = "otp"
+	TotpSeedAttribute     = "TOTP Seed"
+	TotpSettingsAttribute = "TOTP Settings"
+)
+
+var keePassXCHeader = []string{"Group", "Title", "Username", "Password", "URL", "Notes", "TOTP"}
+
+// KeePassXCAttributes returns the attributes KeePassXC stores for the key.
+// The legacy attributes are only set for keys that older versions can read.
+func KeePassXCAttributes(key *otpauth.Key) (map[string]string, error) {
+	u, err := key.URL()
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := map[string]string{OtpAttribute: u}
+	if key.Type == otpauth.TypeTotp && key.Algorithm == otpauth.AlgorithmSHA1 {
+		attributes[TotpSeedAttribute] = otpauth.EncodeSecret(key.Secret)
+		attributes[TotpSettingsAttribute] = strconv.Itoa(key.Period) + ";" + strconv.Itoa(key.Digits)
+	}
+
+	return attributes, nil
+}
+
+// KeyFromKeePassXCAttributes reads the key of a KeePassXC entry with the
+// given title and username. The otp attribute is preferred over the legacy
+// ones.
+func KeyFromKeePassXCAttributes(title, username string, attributes map[string]string) (*otpauth.Key, error) {
+	if value := attributes[OtpAttribute]; len(value) > 0 {
+		return parseTotpField(value, title, username)
+	}
+
+	seed := attributes[TotpSeedAttribute]
+	if len(seed) == 0 {
+		return nil, otpauth.ErrMissingSecret
+	}
+
+	secret, err := otpauth.DecodeSecret(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &otpauth.Key{
+		Type:        otpauth.TypeTotp,
+		Issuer:      title,
+		AccountName: username,
+		Secret:      secret,
+	}
+
+	// Settings are "period;digits", where digits is S for Steam codes
+	if settings := attributes[TotpSettingsAttribute]; len(settings) > 0 {
+		parts := strings.Split(settings, ";")
+		if key.Period, err = strconv.Atoi(parts[0]); err != nil {
+			return nil, fmt.Errorf("%w : %s", otpauth.ErrInvalidPeriod, settings)
+		}
+
+		if len(parts) > 1 {
+			if key.Digits, err = strconv.Atoi(parts[1]); err != nil {
+				return nil, fmt.Errorf("%w : %s", ErrUnsupportedEntry, settings)
+			}
+		}
+	}
+
+	return newKey(key)
+}
+
+// readKeePassXC reads the csv export of KeePassXC. Entries without totp are
+// skipped.
+func readKeePassXC(data []byte) ([]*otpauth.Key, []Skipped, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+
+	columns := columnIndexes(records[0])
+	title, hasTitle := columns["title"]
+	username, hasUsername := columns["username"]
+	totp, hasTotp := columns["totp"]
+	if !hasTitle || !hasUsername || !hasTotp {
+		return nil, nil, fmt.Errorf("%w : title, username or totp column is missing", ErrUnknownFormat)
+	}
+
+	var keys []*otpauth.Key
+	var skipped []Skipped
+	for _, record := range records[1:] {
+		if len(record[totp]) == 0 {
+			continue
+		}
+
+		key, err := KeyFromKeePassXCAttributes(record[title], record[username], map[string]string{
+			OtpAttribute: record[totp],
+		})
+		if errors.Is(err, ErrUnsupportedEntry) {
+			skipped = append(skipped, Skipped{Name: record[title], Err: err})
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s : %w", record[title], err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, skipped, nil
+}
+
+func writeKeePassXC(keys []*otpauth.Key) ([]byte, error) {
+	records := [][]string{keePassXCHeader}
+	for _, key := range keys {
+		attributes, err := KeePassXCAttributes(key)
+		if err != nil {
+			return nil, err
+		}
+
+		title := key.Issuer
+		if len(title) == 0 {
+			title = key.AccountName
+		}
+
+		records = append(records, []string{"Root", title, key.AccountName, "", "", "", attributes[OtpAttribute]})
+	}
+
+	return writeRecords(records)
+}
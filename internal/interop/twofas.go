@@ -0,0 +1,133 @@
+package interop
+
+import (
+	"encoding/json"
+	"fmt"
+	"otp/pkg/otpauth"
+	"strings"
+)
+
+// twoFASSchemaVersion is the schema version of 2FAS backups written here
+const twoFASSchemaVersion = 4
+
+type twoFASBackup struct {
+	Services      []twoFASService `json:"services"`
+	Groups        []interface{}   `json:"groups"`
+	SchemaVersion int             `json:"schemaVersion"`
+	AppVersion    int             `json:"appVersionCode,omitempty"`
+	Encrypted     string          `json:"servicesEncrypted,omitempty"`
+}
+
+type twoFASService struct {
+	Name   string      `json:"name"`
+	Secret string      `json:"secret"`
+	Otp    twoFASOtp   `json:"otp"`
+	Order  twoFASOrder `json:"order"`
+}
+
+type twoFASOtp struct {
+	Label     string `json:"label,omitempty"`
+	Account   string `json:"account"`
+	Issuer    string `json:"issuer,omitempty"`
+	Digits    int    `json:"digits"`
+	Period    int    `json:"period,omitempty"`
+	Algorithm string `json:"algorithm"`
+	TokenType string `json:"tokenType"`
+	Counter   uint64 `json:"counter,omitempty"`
+	Source    string `json:"source"`
+}
+
+type twoFASOrder struct {
+	Position int `json:"position"`
+}
+
+func readTwoFAS(data []byte) ([]*otpauth.Key, []Skipped, error) {
+	var backup twoFASBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, nil, err
+	}
+
+	if len(backup.Encrypted) > 0 {
+		return nil, nil, fmt.Errorf("%w : export 2fas backups without a password", ErrUnsupportedEncryption)
+	}
+
+	var keys []*otpauth.Key
+	var skipped []Skipped
+	for _, service := range backup.Services {
+		entryType, err := parseType(service.Otp.TokenType)
+		if err != nil {
+			skipped = append(skipped, Skipped{Name: service.Name, Err: fmt.Errorf("%w : %s", ErrUnsupportedEntry, service.Otp.TokenType)})
+			continue
+		}
+
+		secret, err := otpauth.DecodeSecret(service.Secret)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		algorithm, err := parseAlgorithm(service.Otp.Algorithm)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		issuer := service.Otp.Issuer
+		if len(issuer) == 0 {
+			issuer = service.Name
+		}
+
+		key, err := newKey(&otpauth.Key{
+			Type:        entryType,
+			Issuer:      issuer,
+			AccountName: service.Otp.Account,
+			Secret:      secret,
+			Algorithm:   algorithm,
+			Digits:      service.Otp.Digits,
+			Period:      service.Otp.Period,
+			Counter:     service.Otp.Counter,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, skipped, nil
+}
+
+func writeTwoFAS(keys []*otpauth.Key) ([]byte, error) {
+	backup := twoFASBackup{
+		Services:      []twoFASService{},
+		Groups:        []interface{}{},
+		SchemaVersion: twoFASSchemaVersion,
+	}
+
+	for i, key := range keys {
+		name := key.Issuer
+		if len(name) == 0 {
+			name = key.AccountName
+		}
+
+		service := twoFASService{
+			Name:   name,
+			Secret: otpauth.EncodeSecret(key.Secret),
+			Otp: twoFASOtp{
+				Account:   key.AccountName,
+				Issuer:    key.Issuer,
+				Digits:    key.Digits,
+				Algorithm: key.Algorithm.String(),
+				TokenType: strings.ToUpper(key.Type),
+				Source:    "Link",
+			},
+			Order: twoFASOrder{Position: i},
+		}
+
+		if key.Type == otpauth.TypeHotp {
+			service.Otp.Counter = key.Counter
+		} else {
+			service.Otp.Period = key.Period
+		}
+		backup.Services = append(backup.Services, service)
+	}
+
+	return json.MarshalIndent(backup, "", "  ")
+}
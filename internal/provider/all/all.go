@@ -0,0 +1,15 @@
+// Package all registers every bank that can activate a token.
+//
+// Ramznegar and Sekeh hand out server generated pins and GbRamz does not
+// return a seed yet, so they have no provider.
+package all
+
+import (
+	_ "otp/internal/apan"
+	_ "otp/internal/aras"
+	_ "otp/internal/eghtesadnovin"
+	_ "otp/internal/rima"
+	_ "otp/internal/saman"
+	_ "otp/internal/sina"
+	_ "otp/internal/tejarat"
+)
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"otp/internal/token"
+	"strings"
+)
+
+// Step is a single round of user input in an activation flow.
+type Step struct {
+	Fields []Field
+
+	// Skip, if set, is checked right before the step becomes current.
+	Skip func() bool
+
+	// Run is called with the submitted values. The step that returns a
+	// token finishes the flow.
+	Run func(values map[string]string) (*token.Token, error)
+}
+
+// Flow is a Session made of consecutive steps. Bank packages use it to
+// describe their activation process without implementing Session themselves.
+type Flow struct {
+	steps   []Step
+	current int
+	token   *token.Token
+}
+
+// NewFlow creates a session that runs the given steps in order.
+func NewFlow(steps ...Step) *Flow {
+	f := &Flow{steps: steps}
+	f.skip()
+	return f
+}
+
+func (f *Flow) skip() {
+	for f.current < len(f.steps) && f.steps[f.current].Skip != nil && f.steps[f.current].Skip() {
+		f.current++
+	}
+}
+
+// Fields returns the values the current step needs.
+func (f *Flow) Fields() []Field {
+	if f.current >= len(f.steps) {
+		return nil
+	}
+	return f.steps[f.current].Fields
+}
+
+// Submit runs the current step with the given values.
+func (f *Flow) Submit(values map[string]string) error {
+	if f.current >= len(f.steps) {
+		return ErrSessionDone
+	}
+
+	step := f.steps[f.current]
+
+	trimmed := make(map[string]string, len(values))
+	for k, v := range values {
+		trimmed[k] = strings.TrimSpace(v)
+	}
+
+	for _, field := range step.Fields {
+		if !field.Optional && len(trimmed[field.Name]) == 0 {
+			return fmt.Errorf("%s is required", field.Name)
+		}
+	}
+
+	t, err := step.Run(trimmed)
+	if err != nil {
+		return err
+	}
+
+	if t != nil {
+		// Requests of the activation have estimated the bank clock
+		t.SyncServerTime()
+		f.token = t
+		f.current = len(f.steps)
+		return nil
+	}
+
+	f.current++
+	f.skip()
+	if f.current == len(f.steps) {
+		return errors.New("activation finished without a token")
+	}
+
+	return nil
+}
+
+// Done reports whether the flow has produced its token.
+func (f *Flow) Done() bool {
+	return f.token != nil
+}
+
+// Token returns the activated token.
+func (f *Flow) Token() (*token.Token, error) {
+	if !f.Done() {
+		return nil, ErrSessionNotDone
+	}
+	return f.token, nil
+}
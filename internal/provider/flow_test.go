@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"otp/internal/clock"
+	"otp/internal/token"
+	"testing"
+	"time"
+)
+
+func TestFlow(t *testing.T) {
+	needsTicket := false
+
+	flow := NewFlow(
+		Step{
+			Fields: []Field{{Name: FieldMobileNumber}},
+			Run: func(values map[string]string) (*token.Token, error) {
+				return nil, nil
+			},
+		},
+		Step{
+			Fields: []Field{{Name: "ticket"}},
+			Skip: func() bool {
+				return !needsTicket
+			},
+			Run: func(values map[string]string) (*token.Token, error) {
+				t.Fatal("skipped step should not run")
+				return nil, nil
+			},
+		},
+		Step{
+			Fields: []Field{{Name: FieldPin}, {Name: FieldCif, Optional: true}},
+			Run: func(values map[string]string) (*token.Token, error) {
+				return &token.Token{Seed: values[FieldPin]}, nil
+			},
+		},
+	)
+
+	if err := flow.Submit(map[string]string{}); err == nil {
+		t.Fatal("missing required field is accepted")
+	}
+
+	if err := flow.Submit(map[string]string{FieldMobileNumber: "09120000000"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if flow.Fields()[0].Name != FieldPin {
+		t.Fatalf("expected pin step, got %v", flow.Fields())
+	}
+
+	if _, err := flow.Token(); err != ErrSessionNotDone {
+		t.Fatalf("expected %v, got %v", ErrSessionNotDone, err)
+	}
+
+	if err := flow.Submit(map[string]string{FieldPin: " 1234 "}); err != nil {
+		t.Fatal(err)
+	}
+
+	otpToken, err := flow.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !flow.Done() || otpToken.Seed != "1234" {
+		t.Fatalf("unexpected token %+v", otpToken)
+	}
+
+	if err := flow.Submit(nil); err != ErrSessionDone {
+		t.Fatalf("expected %v, got %v", ErrSessionDone, err)
+	}
+}
+
+func TestFlowServerTime(t *testing.T) {
+	now := time.Now()
+	clock.Observe("flow.test", now.Add(-time.Minute), 0, now, now)
+
+	flow := NewFlow(Step{
+		Run: func(values map[string]string) (*token.Token, error) {
+			return &token.Token{ServerHost: "flow.test"}, nil
+		},
+	})
+
+	if err := flow.Submit(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	otpToken, err := flow.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !otpToken.UseServerTime || otpToken.ServerOffset != -time.Minute {
+		t.Fatalf("server offset is not kept : %+v", otpToken)
+	}
+}
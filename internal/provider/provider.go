@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"otp/internal/clock"
+	"otp/internal/token"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the values a session asks for. They match the json tags of
+// structs.QrData where one exists, so a decoded activation QR code can be fed
+// to a session as is.
+const (
+	FieldMobileNumber          = "mobileNumber"
+	FieldToken                 = "token"
+	FieldVerificationCode      = "verificationCode"
+	FieldPin                   = "pin"
+	FieldCif                   = "cif"
+	FieldChannelNameInAAServer = "channelNameInAAServer"
+	FieldServiceChannelOtpType = "serviceChannelOtpType"
+	FieldTokenGeneratedTime    = "tokenGeneratedTime"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown provider")
+	ErrSessionDone     = errors.New("session is already done")
+	ErrSessionNotDone  = errors.New("session is not done yet")
+)
+
+// Provider activates OTP tokens of a single bank.
+type Provider interface {
+	// ID returns the unique identifier of the provider, like "104" or "saman".
+	ID() string
+
+	// Name returns the human readable name of the provider.
+	Name() string
+
+	// NewSession starts a new activation.
+	NewSession(options Options) (Session, error)
+}
+
+// Options customizes an activation session.
+type Options struct {
+	// Clock is used in requests that carry the device time. It can be nil.
+	Clock clock.Clock
+
+	// ProtectPin keeps only the server secret in tokens whose seed contains
+	// the pin, so the pin is needed to generate codes.
+	ProtectPin bool
+
+	// StorePinVerifier keeps a salted hash of the pin in protected tokens
+	// to detect wrong pins.
+	StorePinVerifier bool
+}
+
+// Protect removes the pin from the token if the options ask for it.
+func (o Options) Protect(t *token.Token, pin string) error {
+	if !o.ProtectPin {
+		return nil
+	}
+	return t.ProtectWithPin(pin, o.StorePinVerifier)
+}
+
+// Field describes a single value a session needs from the user.
+type Field struct {
+	Name        string
+	Description string
+	Optional    bool
+	Secret      bool
+}
+
+// Session is an activation in progress. Every bank needs one or more rounds
+// of user input (sms codes, pins, QR code data, ...) before it hands out a
+// token, so a session is driven by calling Fields and Submit until Done
+// reports true.
+type Session interface {
+	// Fields returns the values the current step needs.
+	Fields() []Field
+
+	// Submit runs the current step with the given values and moves to the
+	// next one.
+	Submit(values map[string]string) error
+
+	// Done reports whether the activation is finished.
+	Done() bool
+
+	// Token returns the activated token of a finished session.
+	Token() (*token.Token, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Provider{}
+)
+
+// Register makes a provider available by its ID and name.
+// It panics if Register is called twice with the same ID or name.
+func Register(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, key := range []string{p.ID(), p.Name()} {
+		key = strings.ToLower(key)
+		if _, dup := providers[key]; dup {
+			panic("provider: Register called twice for " + key)
+		}
+	}
+
+	providers[strings.ToLower(p.ID())] = p
+	providers[strings.ToLower(p.Name())] = p
+}
+
+// Get returns the provider registered with the given ID or name.
+// Lookups are case insensitive.
+func Get(idOrName string) (Provider, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	p, ok := providers[strings.ToLower(strings.TrimSpace(idOrName))]
+	if !ok {
+		return nil, fmt.Errorf("%w : %s", ErrUnknownProvider, idOrName)
+	}
+
+	return p, nil
+}
+
+// Providers returns all registered providers sorted by ID.
+func Providers() []Provider {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	seen := map[string]bool{}
+	var result []Provider
+	for _, p := range providers {
+		if seen[p.ID()] {
+			continue
+		}
+		seen[p.ID()] = true
+		result = append(result, p)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID() < result[j].ID()
+	})
+
+	return result
+}
+
+// BindChannel limits a token to the password of the channel it is activated
+// for, like CARD_SECOND or CARD_SECOND_PASSWORD. Unknown channels leave the
+// token as is.
+func BindChannel(t *token.Token, channel string) error {
+	slot, ok := token.SlotForChannel(channel)
+	if !ok {
+		return nil
+	}
+	return t.BindSlot(slot)
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+)
+
+type testProvider struct {
+	id, name string
+}
+
+func (p testProvider) ID() string {
+	return p.id
+}
+
+func (p testProvider) Name() string {
+	return p.name
+}
+
+func (p testProvider) NewSession(Options) (Session, error) {
+	return NewFlow(), nil
+}
+
+func TestRegistry(t *testing.T) {
+	Register(testProvider{id: "901", name: "Test Bank"})
+	Register(testProvider{id: "test", name: "Other Test Bank"})
+
+	for _, idOrName := range []string{"901", "Test Bank", " test bank ", "TEST BANK"} {
+		p, err := Get(idOrName)
+		if err != nil {
+			t.Fatalf("%q : %v", idOrName, err)
+		}
+		if p.ID() != "901" {
+			t.Fatalf("%q : expected 901, got %s", idOrName, p.ID())
+		}
+	}
+
+	if _, err := Get("902"); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("expected ErrUnknownProvider, got %v", err)
+	}
+
+	providers := Providers()
+	if len(providers) != 2 || providers[0].ID() != "901" || providers[1].ID() != "test" {
+		t.Fatalf("expected 901 and test once each, got %v", providers)
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	Register(testProvider{id: "903", name: "Third Test Bank"})
+
+	for _, p := range []testProvider{
+		{id: "903", name: "Another Name"},
+		{id: "904", name: "third test bank"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%+v : duplicate provider is registered", p)
+				}
+			}()
+			Register(p)
+		}()
+	}
+
+	if _, err := Get("904"); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("refused provider is registered : %v", err)
+	}
+}
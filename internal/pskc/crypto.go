@@ -0,0 +1,390 @@
+package pskc
+
+import (
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"otp/internal/encryption/symmetric"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Algorithm uris of the encryption, mac and key derivation methods
+const (
+	algorithmAES128CBC = "http://www.w3.org/2001/04/xmlenc#aes128-cbc"
+	algorithmAES192CBC = "http://www.w3.org/2001/04/xmlenc#aes192-cbc"
+	algorithmAES256CBC = "http://www.w3.org/2001/04/xmlenc#aes256-cbc"
+	algorithmKWAES128  = "http://www.w3.org/2001/04/xmlenc#kw-aes128"
+	algorithmKWAES192  = "http://www.w3.org/2001/04/xmlenc#kw-aes192"
+	algorithmKWAES256  = "http://www.w3.org/2001/04/xmlenc#kw-aes256"
+
+	algorithmHMACSHA1   = "http://www.w3.org/2000/09/xmldsig#hmac-sha1"
+	algorithmHMACSHA224 = "http://www.w3.org/2001/04/xmldsig-more#hmac-sha224"
+	algorithmHMACSHA256 = "http://www.w3.org/2001/04/xmldsig-more#hmac-sha256"
+	algorithmHMACSHA384 = "http://www.w3.org/2001/04/xmldsig-more#hmac-sha384"
+	algorithmHMACSHA512 = "http://www.w3.org/2001/04/xmldsig-more#hmac-sha512"
+
+	algorithmPBKDF2 = "http://www.rsasecurity.com/rsalabs/pkcs/schemas/pkcs-5v2-0#pbkdf2"
+)
+
+// Parameters of written containers
+const (
+	preSharedKeyName = "Pre-shared-key"
+	pbkdf2Iterations = 100000
+	pbkdf2SaltSize   = 16
+	macKeySize       = 20
+)
+
+// keyWrapIV is the initial value of RFC 3394 key wrap
+var keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// keyring holds the encryption and mac keys of a container.
+type keyring struct {
+	key          []byte
+	macKey       []byte
+	macAlgorithm string
+}
+
+// newKeyring finds the keys of a container that is read.
+func newKeyring(container *keyContainer, options Options) (*keyring, error) {
+	c := &keyring{key: options.Key}
+
+	if e := container.EncryptionKey; e != nil && e.DerivedKey != nil {
+		if len(options.Password) == 0 {
+			return nil, ErrKeyRequired
+		}
+
+		key, err := deriveKey(&e.DerivedKey.Method, options.Password)
+		if err != nil {
+			return nil, err
+		}
+		c.key = key
+	}
+
+	if m := container.MACMethod; m != nil {
+		c.macAlgorithm = m.Algorithm
+
+		// Without a mac key, values are authenticated with the encryption key
+		c.macKey = c.key
+		if m.MACKey != nil {
+			macKey, err := c.decryptData(m.MACKey)
+			if err != nil {
+				return nil, err
+			}
+			c.macKey = macKey
+		}
+	}
+
+	return c, nil
+}
+
+// newWriteKeyring creates the keys of a container that is written and
+// describes them in the container.
+func newWriteKeyring(container *keyContainer, options Options) (*keyring, error) {
+	c := &keyring{key: options.Key}
+
+	switch {
+	case len(options.Key) > 0:
+		if _, err := cbcAlgorithm(options.Key); err != nil {
+			return nil, err
+		}
+		container.EncryptionKey = &encryptionKey{KeyName: preSharedKeyName}
+	case len(options.Password) > 0:
+		salt, err := randomBytes(pbkdf2SaltSize)
+		if err != nil {
+			return nil, err
+		}
+
+		method := keyDerivationMethod{
+			Algorithm: algorithmPBKDF2,
+			Params: pbkdf2Params{
+				Salt:           base64.StdEncoding.EncodeToString(salt),
+				IterationCount: pbkdf2Iterations,
+				KeyLength:      16,
+				PRF:            &algorithm{},
+			},
+		}
+
+		if c.key, err = deriveKey(&method, options.Password); err != nil {
+			return nil, err
+		}
+		container.EncryptionKey = &encryptionKey{DerivedKey: &derivedKey{Method: method}}
+	default:
+		return c, nil
+	}
+
+	macKey, err := randomBytes(macKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	macKeyData, err := c.encryptData(macKey)
+	if err != nil {
+		return nil, err
+	}
+
+	c.macKey = macKey
+	c.macAlgorithm = algorithmHMACSHA1
+	container.MACMethod = &macMethod{Algorithm: algorithmHMACSHA1, MACKey: macKeyData}
+
+	return c, nil
+}
+
+// decrypt checks the mac of an encrypted value and decrypts it. Aes-cbc
+// values have no integrity check of their own, so RFC 6030 requires a mac
+// for them.
+func (c *keyring) decrypt(v *dataValue) ([]byte, error) {
+	if len(v.ValueMAC) > 0 {
+		if err := c.checkMAC(v); err != nil {
+			return nil, err
+		}
+	} else if isCBC(v.EncryptedValue.Method.Algorithm) {
+		return nil, fmt.Errorf("%w : aes-cbc value without a mac", ErrInvalidMAC)
+	}
+
+	return c.decryptData(v.EncryptedValue)
+}
+
+// checkMAC checks the mac of an encrypted value.
+func (c *keyring) checkMAC(v *dataValue) error {
+	if len(c.macAlgorithm) == 0 {
+		return fmt.Errorf("%w : value mac without a mac method", ErrInvalidContainer)
+	}
+	if len(c.macKey) == 0 {
+		return ErrKeyRequired
+	}
+
+	newHash, err := macHash(c.macAlgorithm)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := decodeBase64(v.EncryptedValue.CipherData.CipherValue)
+	if err != nil {
+		return err
+	}
+
+	expected, err := decodeBase64(v.ValueMAC)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(newHash, c.macKey)
+	mac.Write(ciphertext)
+	if subtle.ConstantTimeCompare(mac.Sum(nil), expected) != 1 {
+		return ErrInvalidMAC
+	}
+	return nil
+}
+
+// encrypt returns a secret as an encrypted value with its mac, or as a plain
+// value if the container is not encrypted.
+func (c *keyring) encrypt(value []byte) (*dataValue, error) {
+	if len(c.key) == 0 {
+		return &dataValue{PlainValue: base64.StdEncoding.EncodeToString(value)}, nil
+	}
+
+	data, err := c.encryptData(value)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := decodeBase64(data.CipherData.CipherValue)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha1.New, c.macKey)
+	mac.Write(ciphertext)
+
+	return &dataValue{
+		EncryptedValue: data,
+		ValueMAC:       base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+	}, nil
+}
+
+func (c *keyring) decryptData(data *encryptedData) ([]byte, error) {
+	if len(c.key) == 0 {
+		return nil, ErrKeyRequired
+	}
+
+	ciphertext, err := decodeBase64(data.CipherData.CipherValue)
+	if err != nil {
+		return nil, err
+	}
+
+	switch data.Method.Algorithm {
+	case algorithmAES128CBC, algorithmAES192CBC, algorithmAES256CBC:
+		if expected, _ := cbcAlgorithm(c.key); expected != data.Method.Algorithm {
+			return nil, fmt.Errorf("%w : %d byte key for %s", ErrDecryption, len(c.key), data.Method.Algorithm)
+		}
+
+		// The iv is the first block of the cipher value
+		if len(ciphertext) < 2*aes.BlockSize || len(ciphertext)%aes.BlockSize != 0 {
+			return nil, fmt.Errorf("%w : cipher value of %d bytes", ErrInvalidContainer, len(ciphertext))
+		}
+
+		plaintext, err := symmetric.NewAES(c.key, symmetric.CBC, symmetric.Pkcs7).
+			Decrypt(ciphertext[aes.BlockSize:], ciphertext[:aes.BlockSize])
+		if err != nil {
+			return nil, ErrDecryption
+		}
+		return plaintext, nil
+	case algorithmKWAES128, algorithmKWAES192, algorithmKWAES256:
+		return unwrapKey(c.key, ciphertext)
+	}
+
+	return nil, fmt.Errorf("%w : %s", ErrUnsupportedAlgorithm, data.Method.Algorithm)
+}
+
+func (c *keyring) encryptData(plaintext []byte) (*encryptedData, error) {
+	method, err := cbcAlgorithm(c.key)
+	if err != nil {
+		return nil, err
+	}
+
+	iv, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := symmetric.NewAES(c.key, symmetric.CBC, symmetric.Pkcs7).
+		Encrypt(append([]byte(nil), plaintext...), iv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedData{
+		Method:     algorithm{Algorithm: method},
+		CipherData: cipherData{CipherValue: base64.StdEncoding.EncodeToString(append(iv, ciphertext...))},
+	}, nil
+}
+
+func isCBC(algorithm string) bool {
+	switch algorithm {
+	case algorithmAES128CBC, algorithmAES192CBC, algorithmAES256CBC:
+		return true
+	}
+	return false
+}
+
+// cbcAlgorithm returns the aes-cbc algorithm for the size of a key.
+func cbcAlgorithm(key []byte) (string, error) {
+	switch len(key) {
+	case 16:
+		return algorithmAES128CBC, nil
+	case 24:
+		return algorithmAES192CBC, nil
+	case 32:
+		return algorithmAES256CBC, nil
+	}
+	return "", fmt.Errorf("%w : %d byte aes key", ErrUnsupportedAlgorithm, len(key))
+}
+
+// deriveKey derives the key of a password based container.
+func deriveKey(method *keyDerivationMethod, password string) ([]byte, error) {
+	if method.Algorithm != algorithmPBKDF2 {
+		return nil, fmt.Errorf("%w : %s", ErrUnsupportedAlgorithm, method.Algorithm)
+	}
+
+	params := method.Params
+	salt, err := decodeBase64(params.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.IterationCount <= 0 {
+		return nil, fmt.Errorf("%w : %d pbkdf2 iterations", ErrInvalidContainer, params.IterationCount)
+	}
+
+	keyLength := params.KeyLength
+	if keyLength == 0 {
+		keyLength = 16
+	}
+
+	// The default prf of PBKDF2 is hmac-sha1
+	newHash := sha1.New
+	if params.PRF != nil && len(params.PRF.Algorithm) > 0 {
+		if newHash, err = macHash(params.PRF.Algorithm); err != nil {
+			return nil, err
+		}
+	}
+
+	return pbkdf2.Key([]byte(password), salt, params.IterationCount, keyLength, newHash), nil
+}
+
+func macHash(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case algorithmHMACSHA1:
+		return sha1.New, nil
+	case algorithmHMACSHA224:
+		return sha256.New224, nil
+	case algorithmHMACSHA256:
+		return sha256.New, nil
+	case algorithmHMACSHA384:
+		return sha512.New384, nil
+	case algorithmHMACSHA512:
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("%w : %s", ErrUnsupportedAlgorithm, algorithm)
+}
+
+// unwrapKey decrypts a key wrapped as described in RFC 3394.
+func unwrapKey(kek, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 24 || len(ciphertext)%8 != 0 {
+		return nil, fmt.Errorf("%w : wrapped key of %d bytes", ErrInvalidContainer, len(ciphertext))
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(ciphertext)/8 - 1
+	a := append([]byte(nil), ciphertext[:8]...)
+	r := append([]byte(nil), ciphertext[8:]...)
+	b := make([]byte, aes.BlockSize)
+
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := binary.BigEndian.Uint64(a) ^ uint64(n*j+i)
+			binary.BigEndian.PutUint64(b, t)
+			copy(b[8:], r[(i-1)*8:i*8])
+
+			block.Decrypt(b, b)
+			copy(a, b[:8])
+			copy(r[(i-1)*8:i*8], b[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(a, keyWrapIV) != 1 {
+		return nil, ErrDecryption
+	}
+	return r, nil
+}
+
+// decodeBase64 decodes base64 that may be split over lines.
+func decodeBase64(s string) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalidContainer, err)
+	}
+	return b, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,393 @@
+// Package pskc reads and writes Portable Symmetric Key Containers, the xml
+// format of RFC 6030 that token vendors and back offices ship seeds in.
+package pskc
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"otp/internal/interop"
+	"otp/internal/token"
+	"otp/pkg/otpauth"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidContainer     = errors.New("pskc: invalid key container")
+	ErrUnsupportedAlgorithm = errors.New("pskc: unsupported algorithm")
+	ErrKeyRequired          = errors.New("pskc: container is encrypted, a key or password is required")
+	ErrDecryption           = errors.New("pskc: can not decrypt value, wrong key or password")
+	ErrInvalidMAC           = errors.New("pskc: value mac does not match")
+)
+
+// Algorithm uris of keys
+const (
+	AlgorithmHotp = "urn:ietf:params:xml:ns:keyprov:pskc:hotp"
+	AlgorithmTotp = "urn:ietf:params:xml:ns:keyprov:pskc:totp"
+)
+
+// Options holds the secrets of encrypted containers. Key is a pre-shared
+// AES key, Password is used by containers with a PBKDF2 derived key. When
+// writing, Key is preferred, and the container is plain if both are empty.
+type Options struct {
+	Key      []byte
+	Password string
+}
+
+// KeyPackage is a key of a container with the device it belongs to.
+type KeyPackage struct {
+	Manufacturer string
+	SerialNo     string
+	KeyID        string
+	Token        *token.Token
+}
+
+type keyContainer struct {
+	XMLName       xml.Name       `xml:"urn:ietf:params:xml:ns:keyprov:pskc KeyContainer"`
+	Version       string         `xml:"Version,attr"`
+	ID            string         `xml:"Id,attr,omitempty"`
+	EncryptionKey *encryptionKey `xml:"EncryptionKey"`
+	MACMethod     *macMethod     `xml:"MACMethod"`
+	KeyPackages   []keyPackage   `xml:"KeyPackage"`
+}
+
+type encryptionKey struct {
+	KeyName    string      `xml:"http://www.w3.org/2000/09/xmldsig# KeyName,omitempty"`
+	DerivedKey *derivedKey `xml:"http://www.w3.org/2009/xmlenc11# DerivedKey"`
+}
+
+type derivedKey struct {
+	Method        keyDerivationMethod `xml:"http://www.w3.org/2009/xmlenc11# KeyDerivationMethod"`
+	MasterKeyName string              `xml:"http://www.w3.org/2009/xmlenc11# MasterKeyName,omitempty"`
+}
+
+type keyDerivationMethod struct {
+	Algorithm string       `xml:"Algorithm,attr"`
+	Params    pbkdf2Params `xml:"http://www.rsasecurity.com/rsalabs/pkcs/schemas/pkcs-5v2-0# PBKDF2-params"`
+}
+
+type pbkdf2Params struct {
+	Salt           string     `xml:"Salt>Specified"`
+	IterationCount int        `xml:"IterationCount"`
+	KeyLength      int        `xml:"KeyLength"`
+	PRF            *algorithm `xml:"PRF"`
+}
+
+type macMethod struct {
+	Algorithm string         `xml:"Algorithm,attr"`
+	MACKey    *encryptedData `xml:"MACKey"`
+}
+
+type algorithm struct {
+	Algorithm string `xml:"Algorithm,attr,omitempty"`
+}
+
+type encryptedData struct {
+	ID         string     `xml:"Id,attr,omitempty"`
+	Method     algorithm  `xml:"http://www.w3.org/2001/04/xmlenc# EncryptionMethod"`
+	CipherData cipherData `xml:"http://www.w3.org/2001/04/xmlenc# CipherData"`
+}
+
+type cipherData struct {
+	CipherValue string `xml:"http://www.w3.org/2001/04/xmlenc# CipherValue"`
+}
+
+type keyPackage struct {
+	DeviceInfo *deviceInfo `xml:"DeviceInfo"`
+	Key        *key        `xml:"Key"`
+}
+
+type deviceInfo struct {
+	Manufacturer string `xml:"Manufacturer,omitempty"`
+	SerialNo     string `xml:"SerialNo,omitempty"`
+}
+
+// key holds the elements of a key in the order of the schema.
+type key struct {
+	ID           string               `xml:"Id,attr"`
+	Algorithm    string               `xml:"Algorithm,attr"`
+	Issuer       string               `xml:"Issuer,omitempty"`
+	Parameters   *algorithmParameters `xml:"AlgorithmParameters"`
+	FriendlyName string               `xml:"FriendlyName,omitempty"`
+	Data         *keyData             `xml:"Data"`
+	UserID       string               `xml:"UserId,omitempty"`
+}
+
+type algorithmParameters struct {
+	Suite          string          `xml:"Suite,omitempty"`
+	ResponseFormat *responseFormat `xml:"ResponseFormat"`
+}
+
+type responseFormat struct {
+	Length   int    `xml:"Length,attr"`
+	Encoding string `xml:"Encoding,attr"`
+}
+
+type keyData struct {
+	Secret       *dataValue `xml:"Secret"`
+	Counter      *dataValue `xml:"Counter"`
+	TimeInterval *dataValue `xml:"TimeInterval"`
+}
+
+// dataValue is a plain or an encrypted value. Plain binary values are base64
+// and plain integers are decimal.
+type dataValue struct {
+	PlainValue     string         `xml:"PlainValue,omitempty"`
+	EncryptedValue *encryptedData `xml:"EncryptedValue"`
+	ValueMAC       string         `xml:"ValueMAC,omitempty"`
+}
+
+// Read returns the key packages of a container. Only HOTP and TOTP keys
+// with decimal responses are supported.
+func Read(data []byte, options Options) ([]*KeyPackage, error) {
+	var container keyContainer
+	if err := xml.Unmarshal(data, &container); err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalidContainer, err)
+	}
+
+	c, err := newKeyring(&container, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []*KeyPackage
+	for i, p := range container.KeyPackages {
+		if p.Key == nil {
+			continue
+		}
+
+		t, err := c.decodeKey(p.Key)
+		if err != nil {
+			return nil, fmt.Errorf("key %d %s : %w", i+1, p.Key.ID, err)
+		}
+
+		kp := &KeyPackage{KeyID: p.Key.ID, Token: t}
+		if p.DeviceInfo != nil {
+			kp.Manufacturer = p.DeviceInfo.Manufacturer
+			kp.SerialNo = p.DeviceInfo.SerialNo
+		}
+
+		// Account ids fall back to the device serial number
+		if len(t.AccountId) == 0 {
+			t.AccountId = kp.SerialNo
+		}
+		if len(t.AccountId) == 0 {
+			t.AccountId = kp.KeyID
+		}
+
+		packages = append(packages, kp)
+	}
+
+	return packages, nil
+}
+
+// Write returns the packages as a container. Secrets are encrypted with
+// aes-cbc and authenticated with hmac-sha1 if options has a key or password.
+// Only the first slot of each token is written, see Packages.
+func Write(packages []*KeyPackage, options Options) ([]byte, error) {
+	container := keyContainer{Version: "1.0"}
+
+	c, err := newWriteKeyring(&container, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range packages {
+		k, err := c.encodeKey(p)
+		if err != nil {
+			return nil, err
+		}
+
+		kp := keyPackage{Key: k}
+		if len(p.Manufacturer) > 0 || len(p.SerialNo) > 0 {
+			kp.DeviceInfo = &deviceInfo{Manufacturer: p.Manufacturer, SerialNo: p.SerialNo}
+		}
+		container.KeyPackages = append(container.KeyPackages, kp)
+	}
+
+	data, err := xml.MarshalIndent(container, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// Tokens returns the tokens of the packages.
+func Tokens(packages []*KeyPackage) []*token.Token {
+	var tokens []*token.Token
+	for _, p := range packages {
+		tokens = append(tokens, p.Token)
+	}
+	return tokens
+}
+
+// Packages returns a package for every distinct slot of the tokens, as
+// interop.KeysFromTokens does with lossy.
+func Packages(tokens []*token.Token, lossy bool) ([]*KeyPackage, error) {
+	keys, err := interop.KeysFromTokens(tokens, lossy)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []*KeyPackage
+	for _, k := range keys {
+		packages = append(packages, &KeyPackage{Token: token.FromKey(k)})
+	}
+	return packages, nil
+}
+
+// decodeKey converts a key of the container.
+func (c *keyring) decodeKey(k *key) (*token.Token, error) {
+	result := &otpauth.Key{
+		Issuer:      k.Issuer,
+		AccountName: k.UserID,
+		Digits:      6,
+		Period:      30,
+	}
+
+	// Drafts of the rfc used other prefixes, so only the suffix is checked
+	switch algorithm := strings.ToLower(k.Algorithm); {
+	case strings.HasSuffix(algorithm, "hotp"):
+		result.Type = otpauth.TypeHotp
+	case strings.HasSuffix(algorithm, "totp"):
+		result.Type = otpauth.TypeTotp
+	default:
+		return nil, fmt.Errorf("%w : %s", ErrUnsupportedAlgorithm, k.Algorithm)
+	}
+
+	if len(result.AccountName) == 0 {
+		result.AccountName = k.FriendlyName
+	}
+
+	if p := k.Parameters; p != nil {
+		if len(p.Suite) > 0 {
+			algorithm, err := otpauth.ParseAlgorithm(strings.TrimPrefix(strings.ToUpper(p.Suite), "HMAC-"))
+			if err != nil {
+				return nil, fmt.Errorf("%w : %s", ErrUnsupportedAlgorithm, p.Suite)
+			}
+			result.Algorithm = algorithm
+		}
+
+		if f := p.ResponseFormat; f != nil {
+			if len(f.Encoding) > 0 && !strings.EqualFold(f.Encoding, "DECIMAL") {
+				return nil, fmt.Errorf("%w : %s responses", ErrUnsupportedAlgorithm, f.Encoding)
+			}
+
+			if f.Length < otpauth.MinDigits || f.Length > otpauth.MaxDigits {
+				return nil, fmt.Errorf("%w : %d", otpauth.ErrInvalidDigits, f.Length)
+			}
+			result.Digits = f.Length
+		}
+	}
+
+	if k.Data == nil || k.Data.Secret == nil {
+		return nil, otpauth.ErrMissingSecret
+	}
+
+	secret, err := c.binary(k.Data.Secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) == 0 {
+		return nil, otpauth.ErrMissingSecret
+	}
+	result.Secret = secret
+
+	if k.Data.Counter != nil {
+		if result.Counter, err = c.integer(k.Data.Counter); err != nil {
+			return nil, err
+		}
+	}
+
+	if k.Data.TimeInterval != nil {
+		interval, err := c.integer(k.Data.TimeInterval)
+		if err != nil {
+			return nil, err
+		}
+		if interval == 0 {
+			return nil, fmt.Errorf("%w : %d", otpauth.ErrInvalidPeriod, interval)
+		}
+		result.Period = int(interval)
+	}
+
+	return token.FromKey(result), nil
+}
+
+// encodeKey converts the first slot of a package token.
+func (c *keyring) encodeKey(p *KeyPackage) (*key, error) {
+	slots := p.Token.SlotNames()
+	source, err := p.Token.Key(slots[0])
+	if err != nil {
+		return nil, err
+	}
+
+	k := &key{
+		ID:        p.KeyID,
+		Algorithm: AlgorithmTotp,
+		Issuer:    source.Issuer,
+		Parameters: &algorithmParameters{
+			ResponseFormat: &responseFormat{Length: source.Digits, Encoding: "DECIMAL"},
+		},
+		Data:   &keyData{},
+		UserID: source.AccountName,
+	}
+
+	if len(k.ID) == 0 {
+		k.ID = uuid.New().String()
+	}
+
+	if source.Algorithm != otpauth.AlgorithmSHA1 {
+		k.Parameters.Suite = "HMAC-" + source.Algorithm.String()
+	}
+
+	if k.Data.Secret, err = c.encrypt(source.Secret); err != nil {
+		return nil, err
+	}
+
+	if source.Type == otpauth.TypeHotp {
+		k.Algorithm = AlgorithmHotp
+		k.Data.Counter = &dataValue{PlainValue: strconv.FormatUint(source.Counter, 10)}
+	} else {
+		k.Data.TimeInterval = &dataValue{PlainValue: strconv.Itoa(source.Period)}
+	}
+
+	return k, nil
+}
+
+// binary returns the bytes of a plain or encrypted value.
+func (c *keyring) binary(v *dataValue) ([]byte, error) {
+	if v.EncryptedValue != nil {
+		return c.decrypt(v)
+	}
+
+	return decodeBase64(v.PlainValue)
+}
+
+// integer returns a plain or encrypted integer. Encrypted integers are big
+// endian.
+func (c *keyring) integer(v *dataValue) (uint64, error) {
+	if v.EncryptedValue == nil {
+		value, err := strconv.ParseUint(strings.TrimSpace(v.PlainValue), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w : %v", ErrInvalidContainer, err)
+		}
+		return value, nil
+	}
+
+	value, err := c.decrypt(v)
+	if err != nil {
+		return 0, err
+	}
+	if len(value) > 8 {
+		return 0, fmt.Errorf("%w : integer of %d bytes", ErrInvalidContainer, len(value))
+	}
+
+	padded := make([]byte, 8)
+	copy(padded[8-len(value):], value)
+	return binary.BigEndian.Uint64(padded), nil
+}
@@ -0,0 +1,293 @@
+package pskc
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"otp/internal/token"
+	"otp/pkg/otpauth"
+	"strings"
+	"testing"
+)
+
+// Figure 6 of RFC 6030, a pre-shared key with aes128-cbc and hmac-sha1
+const preSharedKeyContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<KeyContainer Version="1.0"
+    xmlns="urn:ietf:params:xml:ns:keyprov:pskc"
+    xmlns:ds="http://www.w3.org/2000/09/xmldsig#"
+    xmlns:xenc="http://www.w3.org/2001/04/xmlenc#">
+    <EncryptionKey>
+        <ds:KeyName>Pre-shared-key</ds:KeyName>
+    </EncryptionKey>
+    <MACMethod Algorithm="http://www.w3.org/2000/09/xmldsig#hmac-sha1">
+        <MACKey>
+            <xenc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+            <xenc:CipherData>
+                <xenc:CipherValue>
+    ESIzRFVmd4iZABEiM0RVZgKn6WjLaTC1sbeBMSvIhRejN9vJa2BOlSaMrR7I5wSX
+                </xenc:CipherValue>
+            </xenc:CipherData>
+        </MACKey>
+    </MACMethod>
+    <KeyPackage>
+        <DeviceInfo>
+            <Manufacturer>Manufacturer</Manufacturer>
+            <SerialNo>987654321</SerialNo>
+        </DeviceInfo>
+        <CryptoModuleInfo>
+            <Id>CM_ID_001</Id>
+        </CryptoModuleInfo>
+        <Key Id="12345678" Algorithm="urn:ietf:params:xml:ns:keyprov:pskc:hotp">
+            <Issuer>Issuer</Issuer>
+            <AlgorithmParameters>
+                <ResponseFormat Length="8" Encoding="DECIMAL"/>
+            </AlgorithmParameters>
+            <Data>
+                <Secret>
+                    <EncryptedValue>
+                        <xenc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+                        <xenc:CipherData>
+                            <xenc:CipherValue>
+    AAECAwQFBgcICQoLDA0OD+cIHItlB3Wra1DUpxVvOx2lef1VmNPCMl8jwZqIUqGv
+                            </xenc:CipherValue>
+                        </xenc:CipherData>
+                    </EncryptedValue>
+                    <ValueMAC>Su+NvtQfmvfJzF6bmQiJqoLRExc=
+                    </ValueMAC>
+                </Secret>
+                <Counter>
+                    <PlainValue>0</PlainValue>
+                </Counter>
+            </Data>
+        </Key>
+    </KeyPackage>
+</KeyContainer>`
+
+// Figure 7 of RFC 6030, a key derived from the password "qwerty"
+const passwordContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<pskc:KeyContainer
+    xmlns:pskc="urn:ietf:params:xml:ns:keyprov:pskc"
+    xmlns:xenc11="http://www.w3.org/2009/xmlenc11#"
+    xmlns:pkcs5="http://www.rsasecurity.com/rsalabs/pkcs/schemas/pkcs-5v2-0#"
+    xmlns:xenc="http://www.w3.org/2001/04/xmlenc#" Version="1.0">
+    <pskc:EncryptionKey>
+        <xenc11:DerivedKey>
+            <xenc11:KeyDerivationMethod Algorithm="http://www.rsasecurity.com/rsalabs/pkcs/schemas/pkcs-5v2-0#pbkdf2">
+                <pkcs5:PBKDF2-params>
+                    <Salt>
+                        <Specified>Ej7/PEpyEpw=</Specified>
+                    </Salt>
+                    <IterationCount>1000</IterationCount>
+                    <KeyLength>16</KeyLength>
+                    <PRF/>
+                </pkcs5:PBKDF2-params>
+            </xenc11:KeyDerivationMethod>
+            <xenc:ReferenceList>
+                <xenc:DataReference URI="#ED"/>
+            </xenc:ReferenceList>
+            <xenc11:MasterKeyName>My Password 1</xenc11:MasterKeyName>
+        </xenc11:DerivedKey>
+    </pskc:EncryptionKey>
+    <pskc:MACMethod Algorithm="http://www.w3.org/2000/09/xmldsig#hmac-sha1">
+        <pskc:MACKey>
+            <xenc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+            <xenc:CipherData>
+                <xenc:CipherValue>2GTTnLwM3I4e5IO5FkufoOEiOhNj91fhKRQBtBJYluUDsPOLTfUvoU2dStyOwYZx</xenc:CipherValue>
+            </xenc:CipherData>
+        </pskc:MACKey>
+    </pskc:MACMethod>
+    <pskc:KeyPackage>
+        <pskc:DeviceInfo>
+            <pskc:Manufacturer>TokenVendorAcme</pskc:Manufacturer>
+            <pskc:SerialNo>987654321</pskc:SerialNo>
+        </pskc:DeviceInfo>
+        <pskc:Key Algorithm="urn:ietf:params:xml:ns:keyprov:pskc:hotp" Id="123456">
+            <pskc:Issuer>Example-Issuer</pskc:Issuer>
+            <pskc:AlgorithmParameters>
+                <pskc:ResponseFormat Length="8" Encoding="DECIMAL"/>
+            </pskc:AlgorithmParameters>
+            <pskc:Data>
+                <pskc:Secret>
+                    <pskc:EncryptedValue Id="ED">
+                        <xenc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+                        <xenc:CipherData>
+                            <xenc:CipherValue>oTvo+S22nsmS2Z/RtcoF8Hfh+jzMe0RkiafpoDpnoZTjPYZu6V+A4aEn032yCr4f</xenc:CipherValue>
+                        </xenc:CipherData>
+                    </pskc:EncryptedValue>
+                    <pskc:ValueMAC>LP6xMvjtypbfT9PdkJhBZ+D6O4w=</pskc:ValueMAC>
+                </pskc:Secret>
+            </pskc:Data>
+        </pskc:Key>
+    </pskc:KeyPackage>
+</pskc:KeyContainer>`
+
+// Seed of the rfc examples, "12345678901234567890"
+const rfcSeed = "3132333435363738393031323334353637383930"
+
+func TestReadPreSharedKey(t *testing.T) {
+	key, _ := hex.DecodeString("12345678901234567890123456789012")
+	packages, err := Read([]byte(preSharedKeyContainer), Options{Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(packages) != 1 {
+		t.Fatalf("expected 1 package, got %d", len(packages))
+	}
+
+	p := packages[0]
+	if p.KeyID != "12345678" || p.SerialNo != "987654321" || p.Manufacturer != "Manufacturer" {
+		t.Fatalf("wrong package %+v", p)
+	}
+
+	otpToken := p.Token
+	if otpToken.Seed != rfcSeed || !otpToken.IsHotp() || otpToken.FirstOtpLength != 8 ||
+		otpToken.BankName != "Issuer" || otpToken.AccountId != "987654321" {
+		t.Fatalf("wrong token %+v", otpToken)
+	}
+
+	if _, err := Read([]byte(preSharedKeyContainer), Options{}); !errors.Is(err, ErrKeyRequired) {
+		t.Fatalf("expected ErrKeyRequired, got %v", err)
+	}
+
+	key[0] ^= 1
+	if _, err := Read([]byte(preSharedKeyContainer), Options{Key: key}); err == nil {
+		t.Fatal("read with a wrong key")
+	}
+}
+
+func TestReadMAC(t *testing.T) {
+	key, _ := hex.DecodeString("12345678901234567890123456789012")
+	macKey := preSharedKeyContainer[strings.Index(preSharedKeyContainer, "<MACKey>") : strings.Index(preSharedKeyContainer, "</MACKey>")+len("</MACKey>")]
+	withoutMACKey := strings.Replace(preSharedKeyContainer, macKey, "", 1)
+
+	// Without a mac key, the encryption key authenticates the values
+	if _, err := Read([]byte(withoutMACKey), Options{Key: key}); !errors.Is(err, ErrInvalidMAC) {
+		t.Fatalf("mac of another key : expected ErrInvalidMAC, got %v", err)
+	}
+
+	ciphertext, err := decodeBase64("AAECAwQFBgcICQoLDA0OD+cIHItlB3Wra1DUpxVvOx2lef1VmNPCMl8jwZqIUqGv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha1.New, key)
+	mac.Write(ciphertext)
+	valueMAC := "<ValueMAC>" + base64.StdEncoding.EncodeToString(mac.Sum(nil)) + "</ValueMAC>"
+
+	packages, err := Read([]byte(replaceValueMAC(withoutMACKey, valueMAC)), Options{Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packages[0].Token.Seed != rfcSeed {
+		t.Fatalf("wrong token %+v", packages[0].Token)
+	}
+
+	// Aes-cbc values need a mac
+	if _, err := Read([]byte(replaceValueMAC(preSharedKeyContainer, "")), Options{Key: key}); !errors.Is(err, ErrInvalidMAC) {
+		t.Fatalf("value without a mac : expected ErrInvalidMAC, got %v", err)
+	}
+}
+
+// replaceValueMAC replaces the ValueMAC element of a container.
+func replaceValueMAC(container, valueMAC string) string {
+	start := strings.Index(container, "<ValueMAC>")
+	end := strings.Index(container, "</ValueMAC>") + len("</ValueMAC>")
+	return container[:start] + valueMAC + container[end:]
+}
+
+func TestReadPassword(t *testing.T) {
+	packages, err := Read([]byte(passwordContainer), Options{Password: "qwerty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(packages) != 1 || packages[0].Token.Seed != rfcSeed || packages[0].Token.BankName != "Example-Issuer" {
+		t.Fatalf("wrong packages %+v", packages)
+	}
+
+	if _, err := Read([]byte(passwordContainer), Options{}); !errors.Is(err, ErrKeyRequired) {
+		t.Fatalf("expected ErrKeyRequired, got %v", err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tokens := []*token.Token{
+		token.FromKey(&otpauth.Key{
+			Type:        otpauth.TypeTotp,
+			Issuer:      "Sina",
+			AccountName: "6177236",
+			Secret:      []byte("12345678901234567890"),
+			Algorithm:   otpauth.AlgorithmSHA256,
+			Digits:      8,
+			Period:      60,
+		}),
+		token.FromKey(&otpauth.Key{
+			Type:        otpauth.TypeHotp,
+			AccountName: "hotp",
+			Secret:      []byte{1, 2, 3, 4},
+			Digits:      6,
+			Period:      30,
+			Counter:     42,
+		}),
+	}
+
+	aesKey := bytes.Repeat([]byte{7}, 32)
+	for _, options := range []Options{{}, {Key: aesKey}, {Password: "qwerty"}} {
+		packages, err := Packages(tokens, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		packages[0].SerialNo = "987654321"
+
+		data, err := Write(packages, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		encrypted := len(options.Key) > 0 || len(options.Password) > 0
+		if bytes.Contains(data, []byte("<PlainValue>MTIzNDU2")) == encrypted {
+			t.Fatalf("wrong encryption of secrets\n%s", data)
+		}
+
+		read, err := Read(data, options)
+		if err != nil {
+			t.Fatalf("%v\n%s", err, data)
+		}
+
+		if len(read) != 2 || read[0].SerialNo != "987654321" {
+			t.Fatalf("wrong packages %+v", read)
+		}
+
+		for i, p := range read {
+			expected, actual := tokens[i], p.Token
+			if actual.Seed != expected.Seed || actual.Algorithm != expected.Algorithm || actual.FirstOtpLength != expected.FirstOtpLength ||
+				actual.TimeInterval != expected.TimeInterval || actual.Counter != expected.Counter || actual.Type != expected.Type ||
+				actual.BankName != expected.BankName || actual.AccountId != expected.AccountId {
+				t.Fatalf("expected %+v, got %+v", expected, actual)
+			}
+		}
+	}
+}
+
+func TestUnwrapKey(t *testing.T) {
+	// Section 4.1 of RFC 3394
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	wrapped, _ := hex.DecodeString("1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5")
+
+	key, err := unwrapKey(kek, wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(key) != "00112233445566778899aabbccddeeff" {
+		t.Fatalf("wrong key %x", key)
+	}
+
+	kek[0] ^= 1
+	if _, err := unwrapKey(kek, wrapped); !errors.Is(err, ErrDecryption) {
+		t.Fatalf("expected ErrDecryption, got %v", err)
+	}
+}
@@ -6,9 +6,10 @@ import (
 	"crypto/sha512"
 	"encoding/binary"
 	"encoding/hex"
-	"fmt"
 	"hash"
 	"math"
+	"otp/internal/clock"
+	"otp/internal/token"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +17,7 @@ import (
 
 type Encryption struct {
 	IsAlternateEncryption bool
+	Clock                 clock.Clock
 }
 
 func New() *Encryption {
@@ -40,32 +42,14 @@ func (e *Encryption) otp(hashAlg hash.Hash, timeWindow uint64, otpLength int) st
 	binary.BigEndian.PutUint64(var11, timeWindow)
 
 	hashAlg.Write(var11)
-	var14 := hashAlg.Sum(nil)
-
-	var6 := var14[len(var14)-1]
-
-	for i := 0; i < 4; i++ {
-		var11[i] = var14[i+(int(var6)&15)]
-	}
-
-	var15 := binary.BigEndian.Uint32(var11)
-
-	var7 := uint32(1591523992)
 
+	// The older app masks the truncated hmac with 1591523992
+	profile := token.RefahLegacy
 	if e.IsAlternateEncryption {
-		var7 = 2147483647
+		profile.Mask = 0
 	}
 
-	otpNumber := var15 & var7
-
-	otpNumber %= uint32(math.Pow10(otpLength))
-	otp := fmt.Sprintf("%d", otpNumber)
-	// TODO: Should zeros be appended or prepended?
-	for len(otp) < otpLength {
-		otp += "0"
-	}
-
-	return otp
+	return profile.Format(hashAlg.Sum(nil), otpLength)
 }
 
 func (e *Encryption) getPassword(otpCode, imeiPlusPhoneNumber /* this.imei + this.phoneNumber */, otpToken string, interval int64, otpLength int, appVersion string) string {
@@ -84,7 +68,7 @@ func (e *Encryption) getPassword(otpCode, imeiPlusPhoneNumber /* this.imei + thi
 
 	token = token[0:tokenSaltLocation]
 
-	now := time.Now()
+	now := clock.Now(e.Clock)
 
 	seed := e.makeSeed(token, tokenSaltNumber, imeiPlusPhoneNumber, appMajorVersion)
 	hashAlg := hmac.New(sha256.New, seed)
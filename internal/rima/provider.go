@@ -0,0 +1,70 @@
+package rima
+
+import (
+	"otp/internal/provider"
+	"otp/internal/token"
+)
+
+const (
+	fieldImei           = "imei"
+	fieldActivationCode = "activationCode"
+	fieldGatewayCode    = "gatewayCode"
+	fieldSmsCode        = "smsCode"
+)
+
+type rimaProvider struct{}
+
+func init() {
+	provider.Register(rimaProvider{})
+}
+
+func (rimaProvider) ID() string {
+	return "rima"
+}
+
+func (rimaProvider) Name() string {
+	return "Rima"
+}
+
+func (rimaProvider) NewSession(options provider.Options) (provider.Session, error) {
+	var (
+		api           *Rima
+		registerCode  int
+		activationKey string
+	)
+
+	return provider.NewFlow(
+		provider.Step{
+			Fields: []provider.Field{
+				{Name: provider.FieldMobileNumber, Description: "Mobile number registered in the bank"},
+				{Name: fieldImei, Description: "Device IMEI", Optional: true},
+			},
+			Run: func(values map[string]string) (*token.Token, error) {
+				var err error
+				api = New(values[provider.FieldMobileNumber], values[fieldImei])
+				api.Clock = options.Clock
+				registerCode, err = api.Register()
+				return nil, err
+			},
+		},
+		provider.Step{
+			Fields: []provider.Field{
+				{Name: fieldActivationCode, Description: "Activation code sent by sms"},
+			},
+			Run: func(values map[string]string) (*token.Token, error) {
+				var err error
+				activationKey, err = api.Activate(values[fieldActivationCode], registerCode)
+				return nil, err
+			},
+		},
+		provider.Step{
+			Fields: []provider.Field{
+				{Name: fieldGatewayCode, Description: "Code shown in the bank gateway"},
+				{Name: fieldSmsCode, Description: "Code sent by the bank in sms"},
+			},
+			Run: func(values map[string]string) (*token.Token, error) {
+				return api.GenerateToken(activationKey, values[fieldGatewayCode], values[fieldSmsCode])
+			},
+		},
+	), nil
+}
@@ -7,8 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"otp/internal/clock"
 	"otp/internal/encryption/symmetric"
 	. "otp/internal/structs"
+	"otp/internal/token"
 	. "otp/internal/utils"
 	"otp/pkg/otpauth"
 	"regexp"
@@ -24,6 +26,7 @@ type Rima struct {
 	IMEI         string
 	MobileNo     string
 	AppVersion   string
+	Clock        clock.Clock
 }
 
 var nonAlphaNumericPattern = regexp.MustCompile("[[:^alnum:]]")
@@ -108,7 +111,7 @@ func New(mobileNumber, imei string) *Rima {
 
 func (r *Rima) Register() (int, error) {
 	post := RimaRegisterRequest{
-		CurrentTime:    TimestampMs(),
+		CurrentTime:    TimestampMs(r.Clock),
 		DeviceModel:    r.DeviceModel,
 		DeviceSerial:   r.DeviceSerial,
 		DeviceType:     r.DeviceType,
@@ -165,39 +168,46 @@ func (r *Rima) Activate(activationCode string, registerCode int) (string, error)
 	return resp.Key, nil
 }
 
-func (r *Rima) GenerateSeed(activationKey, gatewayCode, smsCode string) (string, error) {
+type rimaKey struct {
+	bank     string
+	username string
+	secret   string
+	digits   int
+}
+
+func (r *Rima) decodeSmsCode(activationKey, gatewayCode, smsCode string) (*rimaKey, error) {
 	gatewayCode = strings.TrimSpace(gatewayCode)
 	smsCode = strings.TrimSpace(smsCode)
 
 	if !smsCodePattern.MatchString(smsCode) {
-		return "", errors.New("sms code is in wrong format")
+		return nil, errors.New("sms code is in wrong format")
 	}
 
 	accountInfo := accountInfoPattern.FindStringSubmatch(smsCode[0:13])
 	if accountInfo == nil || len(accountInfo) == 0 {
-		return "", errors.New("sms code is in wrong format")
+		return nil, errors.New("sms code is in wrong format")
 	}
 
 	key, err := base64.StdEncoding.DecodeString(activationKey)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	encryptionDataStr := strings.ToUpper(smsCode[13:]) + strings.ToUpper(gatewayCode)
 	encryptedData, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(encryptionDataStr)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	encryption := symmetric.NewAES(key, symmetric.CBC, symmetric.Pkcs5)
 	plainText, err := encryption.Decrypt(encryptedData, []byte(r.IMEI))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	data := plainTextPattern.FindStringSubmatch(string(plainText))
 	if data == nil || len(data) == 0 {
-		return "", errors.New("input data does not match")
+		return nil, errors.New("input data does not match")
 	}
 
 	secret := data[1]
@@ -211,28 +221,57 @@ func (r *Rima) GenerateSeed(activationKey, gatewayCode, smsCode string) (string,
 		otpType = "OTP2"
 		digits = 7
 	default:
-		return "", errors.New("cannot handle this type of otp")
+		return nil, errors.New("cannot handle this type of otp")
 	}
 
 	if accountInfo[3] != data[2] {
-		return "", errors.New("input data does not match")
+		return nil, errors.New("input data does not match")
 	}
 	bank, ok := banks[accountInfo[1]]
 	if !ok {
 		bank = "Bank"
 	}
 
-	username := fmt.Sprintf("%s [%s %sXX XXXX %s]", otpType, accountInfo[1][0:4], accountInfo[1][4:6], accountInfo[2])
+	return &rimaKey{
+		bank:     bank,
+		username: fmt.Sprintf("%s [%s %sXX XXXX %s]", otpType, accountInfo[1][0:4], accountInfo[1][4:6], accountInfo[2]),
+		secret:   secret,
+		digits:   digits,
+	}, nil
+}
+
+func (r *Rima) GenerateSeed(activationKey, gatewayCode, smsCode string) (string, error) {
+	key, err := r.decodeSmsCode(activationKey, gatewayCode, smsCode)
+	if err != nil {
+		return "", err
+	}
 
-	otpAuth, err := otpauth.New(bank, secret)
+	otpAuth, err := otpauth.New(key.bank, key.secret)
 	if err != nil {
 		return "", err
 	}
 
-	otpAuth.SetAccountName(username)
-	otpAuth.SetDigit(digits)
+	otpAuth.SetAccountName(key.username)
+	otpAuth.SetDigit(key.digits)
 	otpAuth.SetPeriod(60)
 	otpAuth.SetAlgorithm(otpauth.AlgorithmSHA256)
 
 	return otpAuth.String(), nil
 }
+
+// GenerateToken does the same as GenerateSeed, but returns a token instead of
+// an otpauth url.
+func (r *Rima) GenerateToken(activationKey, gatewayCode, smsCode string) (*token.Token, error) {
+	seed, err := r.GenerateSeed(activationKey, gatewayCode, smsCode)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := token.FromURL(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	t.ServerHost = "sotp.isc.co.ir"
+	return t, nil
+}
@@ -0,0 +1,62 @@
+package saman
+
+import (
+	"otp/internal/provider"
+	"otp/internal/token"
+	"strconv"
+)
+
+type samanProvider struct{}
+
+func init() {
+	provider.Register(samanProvider{})
+}
+
+func (samanProvider) ID() string {
+	return "saman"
+}
+
+func (samanProvider) Name() string {
+	return "Saman Bank"
+}
+
+func (p samanProvider) NewSession(options provider.Options) (provider.Session, error) {
+	return provider.NewFlow(provider.Step{
+		Fields: []provider.Field{
+			{Name: provider.FieldToken, Description: "Token in the activation QR code"},
+			{Name: provider.FieldCif, Description: "Customer number in the activation QR code"},
+			{Name: provider.FieldChannelNameInAAServer, Description: "Channel name in the activation QR code"},
+			{Name: provider.FieldServiceChannelOtpType, Description: "Otp type in the activation QR code", Optional: true},
+			{Name: provider.FieldTokenGeneratedTime, Description: "Generation time in the activation QR code"},
+			{Name: provider.FieldVerificationCode, Description: "Verification code sent by sms"},
+			{Name: provider.FieldPin, Description: "Arbitrary pin to protect the token", Secret: true},
+		},
+		Run: func(values map[string]string) (*token.Token, error) {
+			return p.activate(values, options)
+		},
+	}), nil
+}
+
+func (samanProvider) activate(values map[string]string, options provider.Options) (*token.Token, error) {
+	tokenGeneratedTime, err := strconv.ParseInt(values[provider.FieldTokenGeneratedTime], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := Activate(values[provider.FieldToken], values[provider.FieldVerificationCode], values[provider.FieldPin], values[provider.FieldCif], values[provider.FieldChannelNameInAAServer], values[provider.FieldServiceChannelOtpType], tokenGeneratedTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := provider.BindChannel(t, values[provider.FieldServiceChannelOtpType]); err != nil {
+		return nil, err
+	}
+
+	if err := options.Protect(t, values[provider.FieldPin]); err != nil {
+		return nil, err
+	}
+
+	t.BankName = "Saman"
+	t.AccountId = values[provider.FieldCif]
+	return t, nil
+}
@@ -87,5 +87,6 @@ func activateToken(urlPath, cif, publicKey, cipherMessage, channelNameInAAServer
 		return nil, errors.New(resp.ErrorCode)
 	}
 
+	resp.Token.ServerHost = "ib.sb24.ir"
 	return &resp.Token, nil
 }
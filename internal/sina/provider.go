@@ -0,0 +1,61 @@
+package sina
+
+import (
+	"otp/internal/provider"
+	"otp/internal/token"
+	"strconv"
+)
+
+type sinaProvider struct{}
+
+func init() {
+	provider.Register(sinaProvider{})
+}
+
+func (sinaProvider) ID() string {
+	return "sina"
+}
+
+func (sinaProvider) Name() string {
+	return "Sina24h"
+}
+
+func (p sinaProvider) NewSession(options provider.Options) (provider.Session, error) {
+	return provider.NewFlow(provider.Step{
+		Fields: []provider.Field{
+			{Name: provider.FieldToken, Description: "Token in the activation QR code"},
+			{Name: provider.FieldCif, Description: "Customer number in the activation QR code"},
+			{Name: provider.FieldChannelNameInAAServer, Description: "MODERN or CARD_SECOND, as in the activation QR code"},
+			{Name: provider.FieldTokenGeneratedTime, Description: "Generation time in the activation QR code"},
+			{Name: provider.FieldVerificationCode, Description: "Verification code sent by sms"},
+			{Name: provider.FieldPin, Description: "Arbitrary pin to protect the token", Secret: true},
+		},
+		Run: func(values map[string]string) (*token.Token, error) {
+			return p.activate(values, options)
+		},
+	}), nil
+}
+
+func (sinaProvider) activate(values map[string]string, options provider.Options) (*token.Token, error) {
+	tokenGeneratedTime, err := strconv.ParseInt(values[provider.FieldTokenGeneratedTime], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := Activate(values[provider.FieldToken], values[provider.FieldVerificationCode], values[provider.FieldPin], values[provider.FieldCif], values[provider.FieldChannelNameInAAServer], tokenGeneratedTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := provider.BindChannel(t, values[provider.FieldChannelNameInAAServer]); err != nil {
+		return nil, err
+	}
+
+	if err := options.Protect(t, values[provider.FieldPin]); err != nil {
+		return nil, err
+	}
+
+	t.BankName = "Sina"
+	t.AccountId = values[provider.FieldCif]
+	return t, nil
+}
@@ -87,5 +87,6 @@ func activateToken(urlPath, cif, publicKey, cipherMessage, channelNameInAAServer
 		return nil, errors.New(resp.ErrorCode)
 	}
 
+	resp.Token.ServerHost = "www.sina24h.com:2007"
 	return &resp.Token, nil
 }
@@ -0,0 +1,183 @@
+// Package store keeps activated tokens by name in a json file.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"otp/internal/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PathEnv overrides the default path of the store.
+const PathEnv = "OTP_STORE"
+
+var (
+	ErrNotFound    = errors.New("no token with this name")
+	ErrExists      = errors.New("a token with this name already exists")
+	ErrInvalidName = errors.New("invalid token name")
+)
+
+// Store is a set of named tokens. Changes are kept in memory until Save.
+type Store struct {
+	path   string
+	tokens map[string]*token.Token
+}
+
+// DefaultPath returns the path in PathEnv, or tokens.json in the otp
+// directory of the user configuration directory.
+func DefaultPath() (string, error) {
+	if path := os.Getenv(PathEnv); len(path) > 0 {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "otp", "tokens.json"), nil
+}
+
+// Open reads the store at path. A missing file is an empty store.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, tokens: map[string]*token.Token{}}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &s.tokens); err != nil {
+		return nil, fmt.Errorf("%s : %w", path, err)
+	}
+	return s, nil
+}
+
+// Path returns the file of the store.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Names returns the names of the tokens, sorted.
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.tokens))
+	for name := range s.tokens {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the token with the given name. Lookups are case insensitive.
+func (s *Store) Get(name string) (*token.Token, error) {
+	t, ok := s.tokens[normalize(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w : %s", ErrNotFound, name)
+	}
+	return t, nil
+}
+
+// Add stores a token with the given name. Unless replace is set, the name
+// must not be taken.
+func (s *Store) Add(name string, t *token.Token, replace bool) error {
+	key := normalize(name)
+	if len(key) == 0 || strings.ContainsAny(key, " \t\n") {
+		return fmt.Errorf("%w : %q", ErrInvalidName, name)
+	}
+
+	if _, ok := s.tokens[key]; ok && !replace {
+		return fmt.Errorf("%w : %s", ErrExists, name)
+	}
+
+	s.tokens[key] = t
+	return nil
+}
+
+// Remove deletes the token with the given name.
+func (s *Store) Remove(name string) error {
+	key := normalize(name)
+	if _, ok := s.tokens[key]; !ok {
+		return fmt.Errorf("%w : %s", ErrNotFound, name)
+	}
+
+	delete(s.tokens, key)
+	return nil
+}
+
+// Save writes the store. The file is only readable by the user, and it is
+// replaced at once so a failed write keeps the previous tokens.
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(s.tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// NameFor returns a free name for a token, made of its bank name and
+// account id, like sina-3922880 or sina-3922880-2.
+func (s *Store) NameFor(t *token.Token) string {
+	var parts []string
+	for _, part := range []string{t.BankName, t.AccountId} {
+		if part = slug(part); len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+
+	base := strings.Join(parts, "-")
+	if len(base) == 0 {
+		base = "token"
+	}
+
+	name := base
+	for i := 2; ; i++ {
+		if _, ok := s.tokens[name]; !ok {
+			return name
+		}
+		name = base + "-" + strconv.Itoa(i)
+	}
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// slug keeps letters and digits of a name and joins the words with dashes.
+func slug(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"otp/internal/token"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "otp", "tokens.json")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sina := &token.Token{OtpLength: 8, TimeInterval: 60000, BankName: "Sina", AccountId: "3922880", Seed: "D2C3E15B8F90E747228BD733A885F6DCEB150968"}
+	if name := s.NameFor(sina); name != "sina-3922880" {
+		t.Fatalf("wrong name %s", name)
+	}
+
+	if err := s.Add("Sina-3922880", sina, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("sina-3922880", sina, false); !errors.Is(err, ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	if err := s.Add("my token", sina, false); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected ErrInvalidName, got %v", err)
+	}
+
+	if name := s.NameFor(sina); name != "sina-3922880-2" {
+		t.Fatalf("wrong free name %s", name)
+	}
+	if name := s.NameFor(&token.Token{BankName: "Eghtesade Novin Bank"}); name != "eghtesade-novin-bank" {
+		t.Fatalf("wrong name %s", name)
+	}
+
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("store is readable by others : %v", info.Mode())
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(s.Names(), []string{"sina-3922880"}) {
+		t.Fatalf("wrong names %v", s.Names())
+	}
+
+	loaded, err := s.Get("SINA-3922880")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, sina) {
+		t.Fatalf("expected %+v, got %+v", sina, loaded)
+	}
+
+	if err := s.Remove("sina-3922880"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("sina-3922880"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	os.Setenv(PathEnv, "/tmp/otp.json")
+	defer os.Unsetenv(PathEnv)
+
+	if path, err := DefaultPath(); err != nil || path != "/tmp/otp.json" {
+		t.Fatalf("expected the path of %s, got %s %v", PathEnv, path, err)
+	}
+}
@@ -2,6 +2,7 @@ package structs
 
 type Bank struct {
 	ID           string   `json:"id"`
+	Name         string   `json:"name"`
 	Phone        string   `json:"phone"`
 	URL          string   `json:"url"`
 	PublicKey    string   `json:"publicKey"`
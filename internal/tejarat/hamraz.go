@@ -7,6 +7,7 @@ import (
 	"errors"
 	"golang.org/x/text/encoding/unicode/utf32"
 	"log"
+	"otp/internal/clock"
 	. "otp/internal/structs"
 	"otp/internal/token"
 	. "otp/internal/utils"
@@ -61,6 +62,8 @@ uK7k5pVUSk4RvZhxVgLBs9A3v0US0ujehtlQd5GE72kItldjP9rcV4A=
 	}
 }
 
+const hamrazHost = "otp.tejaratbank.ir"
+
 type Hamraz struct {
 	imei         string
 	deviceName   string
@@ -72,6 +75,7 @@ type Hamraz struct {
 	mobileNumber string
 	apiKey       string
 	headers      map[string]string
+	clock        clock.Clock
 }
 
 func New(mobileNumber string) (*Hamraz, error) {
@@ -99,6 +103,11 @@ func NewWithDevice(mobileNumber, androidId, osVersion string) (*Hamraz, error) {
 	}, nil
 }
 
+// SetClock sets the clock used in requests, the system clock by default.
+func (h *Hamraz) SetClock(c clock.Clock) {
+	h.clock = c
+}
+
 /*
 func (h *Hamraz) SetApiKey(apiKey string) {
 	h.apiKey = apiKey
@@ -116,9 +125,44 @@ func (h *Hamraz) request(path string, params interface{}, result interface{}) er
 		}
 	}
 
-	uri := "https://otp.tejaratbank.ir/api/" + path
-	_, err = Request(uri, h.headers, payload, &result)
-	return err
+	uri := "https://" + hamrazHost + "/api/" + path
+	sent := time.Now()
+	body, err := Request(uri, h.headers, payload, &result)
+	if err != nil {
+		return err
+	}
+	observeServerTime(body, sent, time.Now())
+	return nil
+}
+
+// observeServerTime feeds the server times in a response to the clock
+// estimator. They are more precise than the Date header.
+func observeServerTime(body []byte, sent, received time.Time) {
+	var resp HamrazChannelsResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return
+	}
+
+	if resp.TimeS > 0 {
+		serverTime, resolution := unixTime(float64(resp.TimeS))
+		clock.Observe(hamrazHost, serverTime, resolution, sent, received)
+	}
+
+	for _, channel := range resp.ChannelPojoList {
+		if channel.CurrentTime > 0 {
+			serverTime, resolution := unixTime(channel.CurrentTime)
+			clock.Observe(hamrazHost, serverTime, resolution, sent, received)
+			break
+		}
+	}
+}
+
+// unixTime converts a server timestamp, in seconds or milliseconds.
+func unixTime(timestamp float64) (time.Time, time.Duration) {
+	if timestamp > 1e11 {
+		return time.Unix(0, int64(timestamp*float64(time.Millisecond))), time.Millisecond
+	}
+	return time.Unix(0, int64(timestamp*float64(time.Second))), time.Second
 }
 
 func (h *Hamraz) GetChannels() ([]HamrazChannel, error) {
@@ -143,7 +187,7 @@ func (h *Hamraz) AddCard(cardNumber, serialNumber, activationCode string, channe
 		LoginID:        cardNumber,
 		PhoneID:        h.androidId,
 		TokenSerialNo:  serialNumber,
-		DateAndTimeMil: int(time.Now().Unix()),
+		DateAndTimeMil: int(clock.Now(h.clock).Unix()),
 	}
 
 	var result HamrazGeneralResponse
@@ -175,6 +219,7 @@ func (h *Hamraz) AddCard(cardNumber, serialNumber, activationCode string, channe
 		TimeInterval:    channel.OtpTimeStep * 1000,
 		BankName:        "Tejarat",
 		AccountId:       cardNumber,
+		ServerHost:      hamrazHost,
 		Seed:            strings.ToUpper(hex.EncodeToString(seed)),
 	}, nil
 }
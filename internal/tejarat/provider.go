@@ -0,0 +1,90 @@
+package tejarat
+
+import (
+	"errors"
+	"otp/internal/provider"
+	. "otp/internal/structs"
+	"otp/internal/token"
+	"strconv"
+	"strings"
+)
+
+const (
+	fieldCardNumber     = "cardNumber"
+	fieldSerialNumber   = "serialNumber"
+	fieldActivationCode = "activationCode"
+	fieldChannel        = "channel"
+)
+
+type hamrazProvider struct{}
+
+func init() {
+	provider.Register(hamrazProvider{})
+}
+
+func (hamrazProvider) ID() string {
+	return "tejarat"
+}
+
+func (hamrazProvider) Name() string {
+	return "Hamraz"
+}
+
+func (hamrazProvider) NewSession(options provider.Options) (provider.Session, error) {
+	var (
+		api      *Hamraz
+		channels []HamrazChannel
+	)
+
+	return provider.NewFlow(
+		provider.Step{
+			Fields: []provider.Field{
+				{Name: provider.FieldMobileNumber, Description: "Mobile number registered in the bank"},
+			},
+			Run: func(values map[string]string) (*token.Token, error) {
+				var err error
+				api, err = New(values[provider.FieldMobileNumber])
+				if err != nil {
+					return nil, err
+				}
+				api.SetClock(options.Clock)
+				channels, err = api.GetChannels()
+				return nil, err
+			},
+		},
+		provider.Step{
+			Fields: []provider.Field{
+				{Name: fieldCardNumber, Description: "Card number"},
+				{Name: fieldSerialNumber, Description: "Token serial number"},
+				{Name: fieldActivationCode, Description: "Activation code"},
+				{Name: fieldChannel, Description: "Channel id or english name, the last channel if empty", Optional: true},
+			},
+			Run: func(values map[string]string) (*token.Token, error) {
+				channel, err := findChannel(channels, values[fieldChannel])
+				if err != nil {
+					return nil, err
+				}
+				return api.AddCard(values[fieldCardNumber], values[fieldSerialNumber], values[fieldActivationCode], channel)
+			},
+		},
+	), nil
+}
+
+func findChannel(channels []HamrazChannel, idOrName string) (HamrazChannel, error) {
+	if len(channels) == 0 {
+		return HamrazChannel{}, errors.New("no channel is available")
+	}
+
+	if len(idOrName) == 0 {
+		return channels[len(channels)-1], nil
+	}
+
+	id, err := strconv.Atoi(idOrName)
+	for _, channel := range channels {
+		if (err == nil && channel.ChannelID == id) || strings.EqualFold(channel.ChannelNameEN, idOrName) {
+			return channel, nil
+		}
+	}
+
+	return HamrazChannel{}, errors.New("unknown channel " + idOrName)
+}
@@ -0,0 +1,159 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrNotTotp = errors.New("token is not time based")
+
+// Code is a generated password with the time window it is valid in.
+// Codes of hotp tokens have no window.
+type Code struct {
+	Value      string    `json:"code"`
+	Step       uint64    `json:"step"`
+	ValidFrom  time.Time `json:"validFrom"`
+	ValidUntil time.Time `json:"validUntil"`
+}
+
+// Remaining returns how long the code is valid after now.
+func (c *Code) Remaining(now time.Time) time.Duration {
+	if c.ValidUntil.IsZero() || now.After(c.ValidUntil) {
+		return 0
+	}
+	return c.ValidUntil.Sub(now)
+}
+
+func (c Code) String() string {
+	return c.Value
+}
+
+// Code returns the current code of the slot.
+func (t *Token) Code(slot Slot) (*Code, error) {
+	return t.CodeAt(slot, t.now())
+}
+
+// CodeAt returns the code of the slot at the given time. Codes of hotp
+// tokens are of the current counter at any time.
+func (t *Token) CodeAt(slot Slot, now time.Time) (*Code, error) {
+	config, err := t.Slot(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	generator, err := t.generator(config)
+	if err != nil {
+		return nil, err
+	}
+
+	step := t.counter(now.Unix(), config.TimeInterval)
+	code := &Code{
+		Value: generator.Generate(step),
+		Step:  step,
+	}
+
+	if !t.IsHotp() {
+		code.ValidFrom = time.Unix(int64(step)*int64(config.period()/time.Second), 0)
+		code.ValidUntil = code.ValidFrom.Add(config.period())
+	}
+
+	return code, nil
+}
+
+// WaitForCode returns a code of the slot that is valid for at least
+// minValidity. If the current one expires sooner, it waits for the next step.
+func (t *Token) WaitForCode(slot Slot, minValidity time.Duration) (*Code, error) {
+	if t.IsHotp() {
+		return nil, ErrNotTotp
+	}
+
+	config, err := t.Slot(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	if minValidity > config.period() {
+		return nil, fmt.Errorf("no code is valid for %v, time step is %v", minValidity, config.period())
+	}
+
+	for {
+		now := t.now()
+		code, err := t.CodeAt(slot, now)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := code.Remaining(now)
+		if remaining >= minValidity {
+			return code, nil
+		}
+
+		time.Sleep(remaining + time.Millisecond)
+	}
+}
+
+// Stream sends the code of the slot as soon as the token steps into a new
+// time window, starting with the current code. The channel is closed when
+// ctx is done.
+func (t *Token) Stream(ctx context.Context, slot Slot) (<-chan Code, error) {
+	if t.IsHotp() {
+		return nil, ErrNotTotp
+	}
+
+	now := t.now()
+	code, err := t.CodeAt(slot, now)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make(chan Code)
+	go func() {
+		defer close(codes)
+
+		for {
+			select {
+			case codes <- *code:
+			case <-ctx.Done():
+				return
+			}
+
+			for {
+				// Wake up right after the step rolls over. If the clock is
+				// behind the expected time, check again shortly.
+				wait := code.Remaining(t.now()) + time.Millisecond
+				if wait < 10*time.Millisecond {
+					wait = 10 * time.Millisecond
+				}
+				if !sleep(ctx, wait) {
+					return
+				}
+
+				next, err := t.CodeAt(slot, t.now())
+				if err != nil {
+					return
+				}
+				if next.Step != code.Step {
+					code = next
+					break
+				}
+			}
+		}
+	}()
+
+	return codes, nil
+}
+
+// sleep waits for d, and reports false if ctx is done sooner.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,82 @@
+package token
+
+import (
+	"context"
+	"encoding/hex"
+	"otp/internal/clock"
+	"testing"
+	"time"
+)
+
+func TestCode(t *testing.T) {
+	otpToken := &Token{
+		FirstOtpLength:  8,
+		SecondOtpLength: 6,
+		TimeInterval:    30000,
+		Seed:            hex.EncodeToString([]byte("12345678901234567890")),
+		Clock:           clock.Fixed(time.Unix(59, 0)),
+	}
+
+	code, err := otpToken.Code(Pin1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code.Value != "94287082" || code.Step != 1 {
+		t.Fatalf("unexpected code %+v", code)
+	}
+
+	if !code.ValidFrom.Equal(time.Unix(30, 0)) || !code.ValidUntil.Equal(time.Unix(60, 0)) {
+		t.Fatalf("unexpected window %v - %v", code.ValidFrom, code.ValidUntil)
+	}
+
+	if code.Remaining(time.Unix(59, 0)) != time.Second {
+		t.Fatalf("unexpected remaining time %v", code.Remaining(time.Unix(59, 0)))
+	}
+
+	next, err := otpToken.CodeAt(Pin1, code.ValidUntil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next.Step != 2 || !next.ValidFrom.Equal(code.ValidUntil) {
+		t.Fatalf("unexpected next code %+v", next)
+	}
+
+	code, err = otpToken.WaitForCode(Pin2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(code.Value) != 6 {
+		t.Fatalf("expected 6 digits, got %s", code.Value)
+	}
+
+	if _, err := otpToken.WaitForCode(Pin1, time.Minute); err == nil {
+		t.Fatal("waiting for a code longer than the time step")
+	}
+}
+
+func TestStream(t *testing.T) {
+	otpToken := &Token{
+		FirstOtpLength: 6,
+		TimeInterval:   1000,
+		Seed:           hex.EncodeToString([]byte("12345678901234567890")),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	codes, err := otpToken.Stream(ctx, Pin1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := <-codes
+	second := <-codes
+	if second.Step != first.Step+1 {
+		t.Fatalf("expected step %d, got %d", first.Step+1, second.Step)
+	}
+
+	cancel()
+	for range codes {
+	}
+}
@@ -0,0 +1,183 @@
+package token
+
+import (
+	"errors"
+	"otp/internal/clock"
+	"otp/pkg/otpauth"
+	"sort"
+	"time"
+)
+
+var ErrNoObservations = errors.New("at least one observed code is needed")
+
+// Observation is a code shown by a bank app at a known time.
+type Observation struct {
+	Time time.Time
+	Code string
+}
+
+// DiscoverOptions limits the parameters Discover tries. Empty fields use
+// the defaults.
+type DiscoverOptions struct {
+	Algorithms []otpauth.Algorithm
+	Periods    []time.Duration
+	Profiles   []Profile
+
+	// MaxOffset is the largest clock offset between the app and the
+	// observation times that is tried.
+	MaxOffset time.Duration
+}
+
+// Match is a set of parameters that reproduces all observed codes.
+type Match struct {
+	Token Token
+
+	// Offset is the difference between the app clock and the observation
+	// times. It is the offset closest to zero that reproduces the codes.
+	Offset time.Duration
+}
+
+var (
+	defaultAlgorithms = []otpauth.Algorithm{otpauth.AlgorithmSHA1, otpauth.AlgorithmSHA256, otpauth.AlgorithmSHA512}
+	defaultPeriods    = []time.Duration{30 * time.Second, 60 * time.Second, 120 * time.Second}
+	defaultProfiles   = []Profile{{Padding: PadAppend}, RFC4226, RefahLegacy}
+)
+
+// offsetRange is a half open range of clock offsets in seconds.
+type offsetRange struct {
+	from, to int64
+}
+
+// Discover searches for the token parameters of a hex seed that reproduce
+// the observed codes. Digits are taken from the length of the codes.
+// Matches are sorted by the size of their clock offset.
+func Discover(seed string, observations []Observation, options DiscoverOptions) ([]Match, error) {
+	if len(observations) == 0 {
+		return nil, ErrNoObservations
+	}
+
+	if len(options.Algorithms) == 0 {
+		options.Algorithms = defaultAlgorithms
+	}
+	if len(options.Periods) == 0 {
+		options.Periods = defaultPeriods
+	}
+	if len(options.Profiles) == 0 {
+		options.Profiles = defaultProfiles
+	}
+	if options.MaxOffset == 0 {
+		options.MaxOffset = 5 * time.Minute
+	}
+
+	var matches []Match
+	for _, algorithm := range options.Algorithms {
+		for _, period := range options.Periods {
+			for _, profile := range options.Profiles {
+				profile := profile
+				t := Token{
+					FirstOtpLength: len(observations[0].Code),
+					TimeInterval:   int(period / time.Millisecond),
+					Algorithm:      algorithm,
+					Profile:        &profile,
+					Seed:           seed,
+				}
+
+				offset, ok, err := t.discoverOffset(observations, int64(options.MaxOffset/time.Second))
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+
+				t.Clock = clock.Offset(offset)
+				matches = append(matches, Match{Token: t, Offset: offset})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return abs(int64(matches[i].Offset)) < abs(int64(matches[j].Offset))
+	})
+
+	return matches, nil
+}
+
+// discoverOffset returns the clock offset closest to zero that makes the
+// token generate all observed codes.
+func (t *Token) discoverOffset(observations []Observation, maxOffset int64) (time.Duration, bool, error) {
+	period := int64(t.TimeInterval / 1000)
+
+	ranges := []offsetRange{{-maxOffset, maxOffset + 1}}
+	for _, observation := range observations {
+		timestamp := observation.Time.Unix()
+
+		generator, err := t.compile(SlotConfig{
+			Seed:         t.Seed,
+			TimeInterval: t.TimeInterval,
+			Length:       len(observation.Code),
+			Algorithm:    t.Algorithm,
+		})
+		if err != nil {
+			return 0, false, err
+		}
+
+		var matched []offsetRange
+		for step := (timestamp - maxOffset) / period; step <= (timestamp+maxOffset)/period; step++ {
+			if step < 0 {
+				continue
+			}
+
+			if generator.Generate(uint64(step)) == observation.Code {
+				matched = append(matched, offsetRange{step*period - timestamp, (step+1)*period - timestamp})
+			}
+		}
+
+		ranges = intersect(ranges, matched)
+		if len(ranges) == 0 {
+			return 0, false, nil
+		}
+	}
+
+	best, found := int64(0), false
+	for _, r := range ranges {
+		closest := r.from
+		if r.from <= 0 && r.to > 0 {
+			closest = 0
+		} else if r.to <= 0 {
+			closest = r.to - 1
+		}
+
+		if !found || abs(closest) < abs(best) {
+			best, found = closest, true
+		}
+	}
+
+	return time.Duration(best) * time.Second, found, nil
+}
+
+func intersect(a, b []offsetRange) []offsetRange {
+	var result []offsetRange
+	for _, x := range a {
+		for _, y := range b {
+			from, to := x.from, x.to
+			if y.from > from {
+				from = y.from
+			}
+			if y.to < to {
+				to = y.to
+			}
+			if from < to {
+				result = append(result, offsetRange{from, to})
+			}
+		}
+	}
+	return result
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,59 @@
+package token
+
+import (
+	"otp/pkg/otpauth"
+	"testing"
+	"time"
+)
+
+func TestDiscover(t *testing.T) {
+	// 778024 is shown at 1652703737 by the Eghtesad Novin app
+	seed := "9D5D96988C8D267DD4FD4B5AF7EFB394A493B85A"
+	shown := time.Unix(1652703737, 0)
+
+	tests := []struct {
+		observed time.Time
+		offset   time.Duration
+	}{
+		{shown, 0},
+		{shown.Add(-100 * time.Second), 83 * time.Second},
+	}
+
+	for _, test := range tests {
+		matches, err := Discover(seed, []Observation{{Time: test.observed, Code: "778024"}}, DiscoverOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(matches) == 0 {
+			t.Fatal("no match found")
+		}
+
+		match := matches[0]
+		if match.Token.Algorithm != otpauth.AlgorithmSHA1 || match.Token.TimeInterval != 60000 || match.Token.FirstOtpLength != 6 {
+			t.Errorf("wrong parameters : %+v", match.Token)
+		}
+
+		if match.Offset != test.offset {
+			t.Errorf("expected offset %v, got %v", test.offset, match.Offset)
+		}
+	}
+}
+
+func TestDiscoverNoMatch(t *testing.T) {
+	matches, err := Discover("9D5D96988C8D267DD4FD4B5AF7EFB394A493B85A", []Observation{
+		{Time: time.Unix(1652703737, 0), Code: "778024"},
+		{Time: time.Unix(1652703737, 0), Code: "000000"},
+	}, DiscoverOptions{MaxOffset: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) != 0 {
+		t.Fatalf("expected no match, got %+v", matches)
+	}
+
+	if _, err := Discover("", nil, DiscoverOptions{}); err != ErrNoObservations {
+		t.Fatalf("expected ErrNoObservations, got %v", err)
+	}
+}
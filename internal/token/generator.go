@@ -0,0 +1,172 @@
+package token
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"sync"
+	"time"
+)
+
+// ErrUnsupportedAlgorithm is returned for algorithms like MD5, whose hmac is
+// too short for the dynamic truncation of RFC 4226.
+var ErrUnsupportedAlgorithm = errors.New("algorithm can not generate codes")
+
+// minSumSize is the hmac size of SHA1, the smallest one truncation can read
+// at every offset.
+const minSumSize = 20
+
+// Generator generates the codes of a single slot with its key decoded once.
+// It is safe for concurrent use, and generating a code with AppendCode does
+// not allocate.
+type Generator struct {
+	profile Profile
+	length  int
+	period  int64
+	pool    sync.Pool
+}
+
+type generatorState struct {
+	mac     hash.Hash
+	counter [8]byte
+	sum     []byte
+}
+
+// Compile returns a generator of the slot. Changes to the token afterwards
+// are not seen by the generator.
+func (t *Token) Compile(slot Slot) (*Generator, error) {
+	config, err := t.Slot(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.compile(config)
+}
+
+func (t *Token) compile(config SlotConfig) (*Generator, error) {
+	key, err := hex.DecodeString(config.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Generator{
+		profile: t.profile(),
+		length:  config.Length,
+		period:  int64(config.TimeInterval / 1000),
+	}
+
+	hashFunction := config.Algorithm.HashFunc()
+	if hashFunction().Size() < minSumSize {
+		return nil, fmt.Errorf("%w : %s", ErrUnsupportedAlgorithm, config.Algorithm)
+	}
+
+	g.pool.New = func() interface{} {
+		mac := hmac.New(hashFunction, key)
+		return &generatorState{
+			mac: mac,
+			sum: make([]byte, 0, mac.Size()),
+		}
+	}
+
+	return g, nil
+}
+
+// generatorKey identifies a generator of a token by everything it uses.
+type generatorKey struct {
+	config  SlotConfig
+	profile Profile
+}
+
+// maxGenerators bounds the generators cached by a token whose fields keep
+// changing.
+const maxGenerators = 16
+
+// generatorCache holds the generators of a single token. A copy of the token
+// does not own the cache of the original and starts its own, so tokens never
+// share a cache or its lock.
+type generatorCache struct {
+	owner      *Token
+	mu         sync.Mutex
+	generators map[generatorKey]*Generator
+}
+
+// cache returns the generator cache of the token. Two first uses at once may
+// both create one, and the one stored last is kept.
+func (t *Token) cache() *generatorCache {
+	c, _ := t.generators.Load().(*generatorCache)
+	if c == nil || c.owner != t {
+		c = &generatorCache{owner: t, generators: map[generatorKey]*Generator{}}
+		t.generators.Store(c)
+	}
+	return c
+}
+
+// generator returns the generator of the config, compiling it only the first
+// time it is used.
+func (t *Token) generator(config SlotConfig) (*Generator, error) {
+	key := generatorKey{config: config, profile: t.profile()}
+	c := t.cache()
+
+	c.mu.Lock()
+	g, ok := c.generators[key]
+	c.mu.Unlock()
+	if ok {
+		return g, nil
+	}
+
+	g, err := t.compile(config)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if len(c.generators) >= maxGenerators {
+		c.generators = map[generatorKey]*Generator{}
+	}
+	c.generators[key] = g
+	c.mu.Unlock()
+
+	return g, nil
+}
+
+// Length returns the number of digits in the codes.
+func (g *Generator) Length() int {
+	return g.length
+}
+
+// Step returns the time step of a time based token at now.
+func (g *Generator) Step(now time.Time) uint64 {
+	if g.period <= 0 {
+		return 0
+	}
+	return uint64(now.Unix() / g.period)
+}
+
+// AppendCode appends the code of the counter to dst.
+func (g *Generator) AppendCode(dst []byte, counter uint64) []byte {
+	state := g.pool.Get().(*generatorState)
+
+	binary.BigEndian.PutUint64(state.counter[:], counter)
+	state.mac.Reset()
+	state.mac.Write(state.counter[:])
+	state.sum = state.mac.Sum(state.sum[:0])
+
+	dst = g.profile.appendCode(dst, state.sum, g.length)
+
+	g.pool.Put(state)
+	return dst
+}
+
+// Generate returns the code of the counter.
+func (g *Generator) Generate(counter uint64) string {
+	var buf [20]byte
+	return string(g.AppendCode(buf[:0], counter))
+}
+
+// At returns the code of a time based token at now.
+func (g *Generator) At(now time.Time) string {
+	return g.Generate(g.Step(now))
+}
@@ -0,0 +1,258 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"math"
+	"otp/internal/clock"
+	"otp/pkg/otpauth"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGenerator(t *testing.T) {
+	otpToken := Token{
+		FirstOtpLength: 8,
+		TimeInterval:   30000,
+		Algorithm:      otpauth.AlgorithmSHA256,
+		Seed:           rfcSeed256,
+		Clock:          clock.Fixed(time.Unix(1111111111, 0)),
+	}
+
+	generator, err := otpToken.Compile(Pin1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otp := generator.At(time.Unix(1111111111, 0)); otp != "67062674" {
+		t.Fatalf("expected 67062674, got %s", otp)
+	}
+
+	// Codes generated concurrently match the token
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for step := uint64(0); step < 100; step++ {
+				now := time.Unix(int64(step)*30+int64(i), 0)
+				otpToken := otpToken
+				otpToken.Clock = clock.Fixed(now)
+
+				expected, err := otpToken.GenerateOtp1()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if otp := generator.At(now); otp != expected {
+					t.Errorf("step %d : expected %s, got %s", step, expected, otp)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// The generator makes the codes of the legacy implementation
+	otpToken = Token{FirstOtpLength: 8, TimeInterval: 30000, Seed: rfcSeed1, Clock: clock.Fixed(time.Unix(1111111111, 0))}
+	expected, err := legacyGenerateOtp(rfcSeed1, 30000, 8, sha1.New, 1111111111000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otp, err := otpToken.GenerateOtp1(); err != nil || otp != expected {
+		t.Fatalf("expected %s, got %s %v", expected, otp, err)
+	}
+}
+
+// Codes of a token follow changes of its fields after its generators are
+// cached
+func TestGeneratorCache(t *testing.T) {
+	otpToken := Token{
+		FirstOtpLength: 8,
+		TimeInterval:   30000,
+		Seed:           rfcSeed1,
+		Clock:          clock.Fixed(time.Unix(59, 0)),
+	}
+
+	steps := []struct {
+		change func()
+		otp    string
+	}{
+		{func() {}, "94287082"},
+		{func() { otpToken.Algorithm, otpToken.Seed = otpauth.AlgorithmSHA256, rfcSeed256 }, "46119246"},
+		{func() { otpToken.FirstOtpLength = 6 }, "119246"},
+		{func() { otpToken.Profile = &Profile{Mask: 1591523992} }, "134728"},
+		{func() {
+			otpToken.Algorithm, otpToken.Seed, otpToken.FirstOtpLength, otpToken.Profile = otpauth.AlgorithmSHA1, rfcSeed1, 8, nil
+		}, "94287082"},
+	}
+
+	for i, step := range steps {
+		step.change()
+
+		otp, err := otpToken.GenerateOtp1()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if otp != step.otp {
+			t.Errorf("change %d : expected %s, got %s", i, step.otp, otp)
+		}
+	}
+}
+
+// A copy of a token, like the ones with another clock offset, does not share
+// the generator cache of the original
+func TestGeneratorCacheCopy(t *testing.T) {
+	otpToken := Token{FirstOtpLength: 8, TimeInterval: 30000, Seed: rfcSeed1, Clock: clock.Fixed(time.Unix(59, 0))}
+	if _, err := otpToken.GenerateOtp1(); err != nil {
+		t.Fatal(err)
+	}
+
+	copied := otpToken
+	copied.Clock = clock.Fixed(time.Unix(1111111109, 0))
+	otp, err := copied.GenerateOtp1()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otp != "70818040" {
+		t.Fatalf("expected 70818040, got %s", otp)
+	}
+	if copied.cache() == otpToken.cache() {
+		t.Fatal("copy shares the generator cache of the original")
+	}
+}
+
+// MD5 has a 16 byte hmac, too short to truncate at every offset
+func TestGeneratorMD5(t *testing.T) {
+	otpToken := Token{
+		FirstOtpLength: 8,
+		TimeInterval:   30000,
+		Algorithm:      otpauth.AlgorithmMD5,
+		Seed:           rfcSeed1,
+		Clock:          clock.Fixed(time.Unix(1111111111, 0)),
+	}
+
+	if _, err := otpToken.Compile(Pin1); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Fatalf("expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+
+	if _, err := otpToken.GenerateOtp1(); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Fatalf("expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+
+	for step := int64(0); step < 64; step++ {
+		if _, err := otpToken.CodeAt(Pin1, time.Unix(step*30, 0)); !errors.Is(err, ErrUnsupportedAlgorithm) {
+			t.Fatalf("expected ErrUnsupportedAlgorithm, got %v", err)
+		}
+	}
+}
+
+func TestGeneratorAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool allocates under the race detector")
+	}
+
+	otpToken := Token{FirstOtpLength: 8, TimeInterval: 30000, Seed: rfcSeed1}
+	generator, err := otpToken.Compile(Pin1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 0, 8)
+	generator.AppendCode(buf, 0)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		generator.AppendCode(buf[:0], 1)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocation, got %v", allocs)
+	}
+}
+
+// legacyGenerateOtp is the code generation before Generator, which decodes
+// the seed, builds an hmac and formats the code with fmt on every call. It
+// is kept to measure the gain of Generator.
+func legacyGenerateOtp(seed string, interval int, otpLength int, hashFunction func() hash.Hash, timestamp int64) (string, error) {
+	key, err := hex.DecodeString(seed)
+	if err != nil {
+		return "", err
+	}
+
+	now := make([]byte, 8)
+	binary.BigEndian.PutUint64(now, uint64(timestamp/int64(interval)))
+
+	h := hmac.New(hashFunction, key)
+	h.Write(now)
+	hmacBytes := h.Sum(nil)
+
+	offset := int(hmacBytes[len(hmacBytes)-1] & 15)
+	otpNumber := binary.BigEndian.Uint32(hmacBytes[offset : offset+4])
+	otpNumber &= 0x7FFFFFFF
+	otpNumber %= uint32(math.Pow10(otpLength))
+	otp := fmt.Sprintf("%d", otpNumber)
+	for len(otp) < otpLength {
+		otp += "0"
+	}
+	return otp, nil
+}
+
+func BenchmarkLegacyGenerateOtp(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := legacyGenerateOtp(rfcSeed1, 30000, 8, sha1.New, 1111111111000); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateOtp(b *testing.B) {
+	otpToken := Token{FirstOtpLength: 8, TimeInterval: 30000, Seed: rfcSeed1, Clock: clock.Fixed(time.Unix(1111111111, 0))}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := otpToken.GenerateOtp1(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerator(b *testing.B) {
+	otpToken := Token{FirstOtpLength: 8, TimeInterval: 30000, Seed: rfcSeed1}
+	generator, err := otpToken.Compile(Pin1)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	buf := make([]byte, 0, 8)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = generator.AppendCode(buf[:0], uint64(i))
+	}
+}
+
+func BenchmarkGeneratorParallel(b *testing.B) {
+	otpToken := Token{FirstOtpLength: 8, TimeInterval: 30000, Seed: rfcSeed1}
+	generator, err := otpToken.Compile(Pin1)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		buf := make([]byte, 0, 8)
+		counter := uint64(0)
+		for pb.Next() {
+			buf = generator.AppendCode(buf[:0], counter)
+			counter++
+		}
+	})
+}
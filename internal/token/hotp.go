@@ -0,0 +1,93 @@
+package token
+
+import "errors"
+
+var (
+	ErrNotHotp      = errors.New("token is not counter based")
+	ErrResyncFailed = errors.New("could not find the counter of the codes")
+)
+
+// NextOtp1 returns the first password for the current counter and advances
+// the counter. The token should be saved afterwards.
+func (t *Token) NextOtp1() (string, error) {
+	return t.nextOtp(Pin1)
+}
+
+// NextOtp2 returns the second password for the current counter and advances
+// the counter. The token should be saved afterwards.
+func (t *Token) NextOtp2() (string, error) {
+	return t.nextOtp(Pin2)
+}
+
+func (t *Token) nextOtp(slot Slot) (string, error) {
+	if !t.IsHotp() {
+		return "", ErrNotHotp
+	}
+
+	config, err := t.Slot(slot)
+	if err != nil {
+		return "", err
+	}
+
+	generator, err := t.generator(config)
+	if err != nil {
+		return "", err
+	}
+
+	otp := generator.Generate(t.Counter)
+	t.Counter++
+	return otp, nil
+}
+
+// Resync looks for two consecutive codes shown by a device in the next
+// lookAhead counters, and sets the counter to the one after them.
+func (t *Token) Resync(code1, code2 string, lookAhead int) error {
+	if !t.IsHotp() {
+		return ErrNotHotp
+	}
+
+	if len(code1) != len(code2) {
+		return errors.New("codes should have the same length")
+	}
+
+	configs, err := t.slotsOfLength(len(code1))
+	if err != nil {
+		return err
+	}
+
+	for _, config := range configs {
+		counter, err := t.resync(config, code1, code2, lookAhead)
+		if err != nil {
+			return err
+		}
+		if counter > 0 {
+			t.Counter = counter
+			return nil
+		}
+	}
+
+	return ErrResyncFailed
+}
+
+// resync returns the counter after the two codes in the slot, or zero if
+// they are not found.
+func (t *Token) resync(config SlotConfig, code1, code2 string, lookAhead int) (uint64, error) {
+	generator, err := t.compile(config)
+	if err != nil {
+		return 0, err
+	}
+
+	otp := generator.Generate(t.Counter)
+	for i := 0; i <= lookAhead; i++ {
+		counter := t.Counter + uint64(i)
+
+		next := generator.Generate(counter + 1)
+		if otp == code1 && next == code2 {
+			return counter + 2, nil
+		}
+
+		otp = next
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,81 @@
+package token
+
+import (
+	"encoding/hex"
+	"otp/pkg/otpauth"
+	"testing"
+)
+
+// Test vectors of RFC 4226 appendix D
+var hotpVectors = []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+func newHotpToken() *Token {
+	return &Token{
+		FirstOtpLength: 6,
+		Type:           TypeHotp,
+		Seed:           hex.EncodeToString([]byte("12345678901234567890")),
+	}
+}
+
+func TestNextOtp(t *testing.T) {
+	otpToken := newHotpToken()
+
+	for i, expected := range hotpVectors {
+		otp, err := otpToken.NextOtp1()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if otp != expected {
+			t.Fatalf("counter %d : expected %s, got %s", i, expected, otp)
+		}
+	}
+
+	if otpToken.Counter != uint64(len(hotpVectors)) {
+		t.Fatalf("counter is not advanced : %d", otpToken.Counter)
+	}
+
+	if _, err := (&Token{}).NextOtp1(); err != ErrNotHotp {
+		t.Fatalf("expected %v, got %v", ErrNotHotp, err)
+	}
+}
+
+func TestResync(t *testing.T) {
+	otpToken := newHotpToken()
+	otpToken.Counter = 1
+
+	if err := otpToken.Resync(hotpVectors[6], hotpVectors[7], 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if otpToken.Counter != 8 {
+		t.Fatalf("expected counter 8, got %d", otpToken.Counter)
+	}
+
+	if err := otpToken.Resync(hotpVectors[2], hotpVectors[3], 10); err != ErrResyncFailed {
+		t.Fatalf("expected %v, got %v", ErrResyncFailed, err)
+	}
+
+	if otpToken.Counter != 8 {
+		t.Fatalf("failed resync changed the counter to %d", otpToken.Counter)
+	}
+}
+
+func TestHotpUrl(t *testing.T) {
+	otpToken := newHotpToken()
+	otpToken.Counter = 42
+
+	url, err := otpToken.GeneralOtp1Url("bank")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otpAuth, err := otpauth.NewKeyFromURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otpAuth.Type() != TypeHotp || otpAuth.Counter() != 42 {
+		t.Fatalf("counter is lost in %s", url)
+	}
+}
@@ -0,0 +1,277 @@
+package token
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"otp/pkg/otpauth"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidOcraSuite = errors.New("invalid ocra suite")
+	ErrInvalidChallenge = errors.New("invalid ocra challenge")
+)
+
+// Challenge formats of an ocra suite
+const (
+	ChallengeNumeric = 'N'
+	ChallengeAlpha   = 'A'
+	ChallengeHex     = 'H'
+)
+
+// OcraSuite is a parsed RFC 6287 suite, like OCRA-1:HOTP-SHA1-6:QN08.
+type OcraSuite struct {
+	Algorithm otpauth.Algorithm
+	Digits    int
+
+	Counter         bool
+	ChallengeFormat byte
+	ChallengeLength int
+
+	// PasswordAlgorithm hashes the pin when Password is set.
+	Password          bool
+	PasswordAlgorithm otpauth.Algorithm
+
+	SessionLength int
+	TimeStep      time.Duration
+
+	suite string
+}
+
+// OcraInput holds the data a code is computed for.
+type OcraInput struct {
+	// Counter is nil when the token counter should be used, so counter 0
+	// can be given.
+	Counter *uint64
+
+	// Challenge is the question of the suite format. For banks it is the
+	// transaction amount or reference number.
+	Challenge string
+
+	// MutualChallenge is the second challenge of a mutual
+	// challenge-response, which is appended to Challenge.
+	MutualChallenge string
+
+	// Password is the pin, hashed with the password algorithm of the suite.
+	Password string
+
+	// Session is the hex encoded session information.
+	Session string
+
+	Time time.Time
+}
+
+// ParseOcraSuite parses an ocra suite string.
+func ParseOcraSuite(suite string) (*OcraSuite, error) {
+	parts := strings.Split(suite, ":")
+	if len(parts) != 3 || parts[0] != "OCRA-1" {
+		return nil, fmt.Errorf("%w : %s", ErrInvalidOcraSuite, suite)
+	}
+
+	s := &OcraSuite{suite: suite}
+
+	function := strings.Split(parts[1], "-")
+	if len(function) != 3 || function[0] != "HOTP" {
+		return nil, fmt.Errorf("%w : crypto function %s", ErrInvalidOcraSuite, parts[1])
+	}
+
+	var err error
+	s.Algorithm, err = ocraAlgorithm(function[1])
+	if err != nil {
+		return nil, err
+	}
+
+	s.Digits, err = strconv.Atoi(function[2])
+	if err != nil || (s.Digits != 0 && (s.Digits < 4 || s.Digits > 10)) {
+		return nil, fmt.Errorf("%w : digits %s", ErrInvalidOcraSuite, function[2])
+	}
+
+	for i, input := range strings.Split(parts[2], "-") {
+		switch {
+		case input == "C" && i == 0:
+			s.Counter = true
+		case strings.HasPrefix(input, "Q") && len(input) == 4:
+			s.ChallengeFormat = input[1]
+			if s.ChallengeFormat != ChallengeNumeric && s.ChallengeFormat != ChallengeAlpha && s.ChallengeFormat != ChallengeHex {
+				return nil, fmt.Errorf("%w : challenge %s", ErrInvalidOcraSuite, input)
+			}
+
+			s.ChallengeLength, err = strconv.Atoi(input[2:])
+			if err != nil || s.ChallengeLength < 4 || s.ChallengeLength > 64 {
+				return nil, fmt.Errorf("%w : challenge %s", ErrInvalidOcraSuite, input)
+			}
+		case strings.HasPrefix(input, "P"):
+			s.Password = true
+			s.PasswordAlgorithm, err = ocraAlgorithm(input[1:])
+			if err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(input, "S") && len(input) == 4:
+			s.SessionLength, err = strconv.Atoi(input[1:])
+			if err != nil || s.SessionLength <= 0 {
+				return nil, fmt.Errorf("%w : session %s", ErrInvalidOcraSuite, input)
+			}
+		case strings.HasPrefix(input, "T") && len(input) > 2:
+			s.TimeStep, err = ocraTimeStep(input[1:])
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w : data input %s", ErrInvalidOcraSuite, input)
+		}
+	}
+
+	if s.ChallengeFormat == 0 {
+		return nil, fmt.Errorf("%w : challenge is required", ErrInvalidOcraSuite)
+	}
+
+	return s, nil
+}
+
+func ocraAlgorithm(name string) (otpauth.Algorithm, error) {
+	switch name {
+	case "SHA1":
+		return otpauth.AlgorithmSHA1, nil
+	case "SHA256":
+		return otpauth.AlgorithmSHA256, nil
+	case "SHA512":
+		return otpauth.AlgorithmSHA512, nil
+	}
+	return 0, fmt.Errorf("%w : algorithm %s", ErrInvalidOcraSuite, name)
+}
+
+func ocraTimeStep(step string) (time.Duration, error) {
+	units := map[byte]time.Duration{'S': time.Second, 'M': time.Minute, 'H': time.Hour}
+
+	unit, ok := units[step[len(step)-1]]
+	n, err := strconv.Atoi(step[:len(step)-1])
+	if !ok || err != nil || n <= 0 {
+		return 0, fmt.Errorf("%w : time step %s", ErrInvalidOcraSuite, step)
+	}
+
+	return time.Duration(n) * unit, nil
+}
+
+func (s *OcraSuite) String() string {
+	return s.suite
+}
+
+// question returns the challenges as the 128 bytes of the data input.
+func (s *OcraSuite) question(challenges ...string) ([]byte, error) {
+	// The length of the suite, like 08 of QN08, is the maximum length of
+	// each challenge in its format
+	for _, challenge := range challenges {
+		if len(challenge) > s.ChallengeLength {
+			return nil, fmt.Errorf("%w : %q is longer than %d", ErrInvalidChallenge, challenge, s.ChallengeLength)
+		}
+	}
+	challenge := strings.Join(challenges, "")
+
+	var q string
+	switch s.ChallengeFormat {
+	case ChallengeNumeric:
+		if strings.Trim(challenge, "0123456789") != "" {
+			return nil, fmt.Errorf("%w : %q is not numeric", ErrInvalidChallenge, challenge)
+		}
+		n, ok := new(big.Int).SetString(challenge, 10)
+		if !ok {
+			return nil, fmt.Errorf("%w : %q is not numeric", ErrInvalidChallenge, challenge)
+		}
+		q = strings.ToUpper(n.Text(16))
+	case ChallengeAlpha:
+		q = hex.EncodeToString([]byte(challenge))
+	case ChallengeHex:
+		q = challenge
+	}
+
+	// The hex form is padded on the right, as the RFC reference code does
+	q += strings.Repeat("0", 256-len(q))
+	return hex.DecodeString(q)
+}
+
+// Generate computes the ocra code of the hex encoded key for the input.
+// Suites with 0 digits return the full hmac in hex.
+func (s *OcraSuite) Generate(key string, input OcraInput) (string, error) {
+	k, err := hex.DecodeString(key)
+	if err != nil {
+		return "", err
+	}
+
+	data := append([]byte(s.suite), 0)
+
+	if s.Counter {
+		if input.Counter == nil {
+			return "", errors.New("counter is required")
+		}
+		counter := make([]byte, 8)
+		binary.BigEndian.PutUint64(counter, *input.Counter)
+		data = append(data, counter...)
+	}
+
+	question, err := s.question(input.Challenge, input.MutualChallenge)
+	if err != nil {
+		return "", err
+	}
+	data = append(data, question...)
+
+	if s.Password {
+		h := s.PasswordAlgorithm.Hash()
+		h.Write([]byte(input.Password))
+		data = append(data, h.Sum(nil)...)
+	}
+
+	if s.SessionLength > 0 {
+		session, err := hex.DecodeString(input.Session)
+		if err != nil {
+			return "", err
+		}
+		if len(session) > s.SessionLength {
+			return "", fmt.Errorf("session is longer than %d bytes", s.SessionLength)
+		}
+		data = append(data, make([]byte, s.SessionLength-len(session))...)
+		data = append(data, session...)
+	}
+
+	if s.TimeStep > 0 {
+		steps := make([]byte, 8)
+		binary.BigEndian.PutUint64(steps, uint64(input.Time.Unix()/int64(s.TimeStep/time.Second)))
+		data = append(data, steps...)
+	}
+
+	return s.sign(k, data), nil
+}
+
+func (s *OcraSuite) sign(key, data []byte) string {
+	h := hmac.New(s.Algorithm.HashFunc(), key)
+	h.Write(data)
+	sum := h.Sum(nil)
+
+	if s.Digits == 0 {
+		return strings.ToUpper(hex.EncodeToString(sum))
+	}
+	return RFC4226.Format(sum, s.Digits)
+}
+
+// OcraCode computes the ocra code of the token seed for the input. The
+// token counter and clock are used when the input does not set them.
+func (t *Token) OcraCode(suite *OcraSuite, input OcraInput) (string, error) {
+	if t.IsPinProtected() {
+		return "", ErrPinRequired
+	}
+
+	if suite.Counter && input.Counter == nil {
+		counter := t.Counter
+		input.Counter = &counter
+	}
+	if suite.TimeStep > 0 && input.Time.IsZero() {
+		input.Time = t.now()
+	}
+
+	return suite.Generate(t.Seed, input)
+}
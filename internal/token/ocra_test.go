@@ -0,0 +1,206 @@
+package token
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	ocraSeed20 = "3132333435363738393031323334353637383930"
+	ocraSeed32 = "3132333435363738393031323334353637383930313233343536373839303132"
+	ocraSeed64 = "31323334353637383930313233343536373839303132333435363738393031323334353637383930313233343536373839303132333435363738393031323334"
+)
+
+func numericQuestions(n int) []string {
+	var questions []string
+	for i := 0; i < n; i++ {
+		questions = append(questions, strings.Repeat(string(rune('0'+i)), 8))
+	}
+	return questions
+}
+
+// Test vectors of RFC 6287 appendix C
+func TestOcra(t *testing.T) {
+	tests := []struct {
+		suite     string
+		key       string
+		counters  bool
+		questions []string
+		password  string
+		time      time.Time
+		codes     []string
+	}{
+		{
+			suite:     "OCRA-1:HOTP-SHA1-6:QN08",
+			key:       ocraSeed20,
+			questions: numericQuestions(10),
+			codes:     []string{"237653", "243178", "653583", "740991", "608993", "388898", "816933", "224598", "750600", "294470"},
+		},
+		{
+			suite:     "OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1",
+			key:       ocraSeed32,
+			counters:  true,
+			questions: []string{"12345678"},
+			password:  "1234",
+			codes:     []string{"65347737", "86775851", "78192410", "71565254", "10104329", "65983500", "70069104", "91771096", "75011558", "08522129"},
+		},
+		{
+			suite:     "OCRA-1:HOTP-SHA256-8:QN08-PSHA1",
+			key:       ocraSeed32,
+			questions: numericQuestions(5),
+			password:  "1234",
+			codes:     []string{"83238735", "01501458", "17957585", "86776967", "86807031"},
+		},
+		{
+			suite:     "OCRA-1:HOTP-SHA512-8:C-QN08",
+			key:       ocraSeed64,
+			counters:  true,
+			questions: numericQuestions(10),
+			codes:     []string{"07016083", "63947962", "70123924", "25341727", "33203315", "34205738", "44343969", "51946085", "20403879", "31409299"},
+		},
+		{
+			suite:     "OCRA-1:HOTP-SHA512-8:QN08-T1M",
+			key:       ocraSeed64,
+			questions: numericQuestions(5),
+			time:      time.Unix(0x132d0b6*60, 0),
+			codes:     []string{"95209754", "55907591", "22048402", "24218844", "36209546"},
+		},
+		{
+			suite:     "OCRA-1:HOTP-SHA256-8:QA08",
+			key:       ocraSeed32,
+			questions: []string{"CLI22220SRV11110", "CLI22221SRV11111", "CLI22222SRV11112"},
+			codes:     []string{"28247970", "01984843", "65387857"},
+		},
+		{
+			suite:     "OCRA-1:HOTP-SHA512-8:QA10-T1M",
+			key:       ocraSeed64,
+			questions: []string{"SIG1000000", "SIG1100000"},
+			time:      time.Unix(0x132d0b6*60, 0),
+			codes:     []string{"77537423", "31970405"},
+		},
+	}
+
+	for _, test := range tests {
+		suite, err := ParseOcraSuite(test.suite)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, expected := range test.codes {
+			input := OcraInput{
+				Password: test.password,
+				Time:     test.time,
+			}
+			if test.counters {
+				counter := uint64(i)
+				input.Counter = &counter
+			}
+			if len(test.questions) == 1 {
+				input.Challenge = test.questions[0]
+			} else {
+				input.Challenge = test.questions[i]
+			}
+
+			// Mutual questions are two challenges of the suite length
+			if len(input.Challenge) > suite.ChallengeLength {
+				input.Challenge, input.MutualChallenge = input.Challenge[:suite.ChallengeLength], input.Challenge[suite.ChallengeLength:]
+			}
+
+			code, err := suite.Generate(test.key, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if code != expected {
+				t.Errorf("%s #%d : expected %s, got %s", test.suite, i, expected, code)
+			}
+		}
+	}
+}
+
+func TestOcraTokenCode(t *testing.T) {
+	suite, err := ParseOcraSuite("OCRA-1:HOTP-SHA512-8:C-QN08")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otpToken := Token{Seed: ocraSeed64, Counter: 3}
+	code, err := otpToken.OcraCode(suite, OcraInput{Challenge: "33333333"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != "25341727" {
+		t.Fatalf("expected 25341727, got %s", code)
+	}
+
+	// Counter 0 is not replaced by the token counter
+	var zero uint64
+	code, err = otpToken.OcraCode(suite, OcraInput{Counter: &zero, Challenge: "00000000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != "07016083" {
+		t.Fatalf("expected 07016083, got %s", code)
+	}
+}
+
+func TestOcraChallenge(t *testing.T) {
+	tests := []struct {
+		suite     string
+		challenge string
+		valid     bool
+	}{
+		{"OCRA-1:HOTP-SHA1-6:QN08", "12345678", true},
+		{"OCRA-1:HOTP-SHA1-6:QN08", "1234", true},
+		{"OCRA-1:HOTP-SHA1-6:QN08", "123456789", false},
+		{"OCRA-1:HOTP-SHA1-6:QN08", "+1234", false},
+		{"OCRA-1:HOTP-SHA1-6:QN08", "12a4", false},
+		{"OCRA-1:HOTP-SHA256-8:QA08", "CLI22220", true},
+		{"OCRA-1:HOTP-SHA256-8:QA08", "CLI22220SRV11110", false},
+		{"OCRA-1:HOTP-SHA1-6:QH10", "0123456789", true},
+		{"OCRA-1:HOTP-SHA1-6:QH10", "0123456789A", false},
+	}
+
+	for _, test := range tests {
+		suite, err := ParseOcraSuite(test.suite)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = suite.Generate(ocraSeed20, OcraInput{Challenge: test.challenge})
+		if test.valid && err != nil {
+			t.Errorf("%s %s : %v", test.suite, test.challenge, err)
+		}
+		if !test.valid && !errors.Is(err, ErrInvalidChallenge) {
+			t.Errorf("%s %s : expected ErrInvalidChallenge, got %v", test.suite, test.challenge, err)
+		}
+	}
+}
+
+func TestParseOcraSuite(t *testing.T) {
+	suite, err := ParseOcraSuite("OCRA-1:HOTP-SHA256-6:C-QA10-PSHA256-S064-T30S")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !suite.Counter || suite.ChallengeFormat != ChallengeAlpha || suite.ChallengeLength != 10 || !suite.Password || suite.SessionLength != 64 || suite.TimeStep != 30*time.Second {
+		t.Fatalf("wrong suite : %+v", suite)
+	}
+
+	for _, invalid := range []string{
+		"OCRA-2:HOTP-SHA1-6:QN08",
+		"OCRA-1:HOTP-MD5-6:QN08",
+		"OCRA-1:HOTP-SHA1-3:QN08",
+		"OCRA-1:HOTP-SHA1-6:C",
+		"OCRA-1:HOTP-SHA1-6:QX08",
+		"OCRA-1:HOTP-SHA1-6:QN08-T1D",
+	} {
+		if _, err := ParseOcraSuite(invalid); !errors.Is(err, ErrInvalidOcraSuite) {
+			t.Errorf("%s : expected ErrInvalidOcraSuite, got %v", invalid, err)
+		}
+	}
+}
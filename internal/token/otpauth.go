@@ -0,0 +1,119 @@
+package token
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"otp/pkg/otpauth"
+	"strings"
+)
+
+var ErrNotPortable = errors.New("token codes can not be generated by authenticator apps")
+
+// FromKey returns a token that generates the codes of an otpauth key, as
+// authenticator apps do.
+func FromKey(key *otpauth.Key) *Token {
+	profile := RFC4226
+	t := &Token{
+		FirstOtpLength:  key.Digits,
+		SecondOtpLength: key.Digits,
+		OtpLength:       key.Digits,
+		TimeInterval:    key.Period * 1000,
+		Algorithm:       key.Algorithm,
+		Profile:         &profile,
+		BankName:        key.Issuer,
+		AccountId:       key.AccountName,
+		Seed:            strings.ToUpper(hex.EncodeToString(key.Secret)),
+	}
+
+	if key.Type == otpauth.TypeHotp {
+		t.Type = TypeHotp
+		t.Counter = key.Counter
+	}
+
+	return t
+}
+
+// FromURL parses an otpauth url into a token.
+func FromURL(rawURL string) (*Token, error) {
+	key, err := otpauth.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return FromKey(key), nil
+}
+
+// FromOtpAuth converts an otpauth url into a token.
+func FromOtpAuth(otpAuth *otpauth.OtpAuth) (*Token, error) {
+	key, err := otpAuth.Key()
+	if err != nil {
+		return nil, err
+	}
+	return FromKey(key), nil
+}
+
+// Key returns the otpauth key of the slot, with the bank name as issuer and
+// the account id as account name. Authenticator apps prepend zeros to short
+// codes, so tokens that append them return ErrNotPortable, see LossyKey.
+func (t *Token) Key(slot Slot) (*otpauth.Key, error) {
+	if t.profile().Padding != PadPrepend {
+		return nil, fmt.Errorf("%w : short codes have zeros appended", ErrNotPortable)
+	}
+	return t.LossyKey(slot)
+}
+
+// LossyKey returns the key of the slot even if the token appends zeros to
+// short codes. About one code in ten needs padding, and then authenticator
+// apps show another code than the token. Tokens with another mask return
+// ErrNotPortable.
+func (t *Token) LossyKey(slot Slot) (*otpauth.Key, error) {
+	if t.profile().mask() != defaultMask {
+		return nil, ErrNotPortable
+	}
+
+	config, err := t.Slot(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := hex.DecodeString(config.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &otpauth.Key{
+		Type:        otpauth.TypeTotp,
+		Issuer:      t.BankName,
+		AccountName: t.AccountId,
+		Secret:      secret,
+		Algorithm:   config.Algorithm,
+		Digits:      config.Length,
+		Period:      config.TimeInterval / 1000,
+	}
+
+	if t.IsHotp() {
+		key.Type = otpauth.TypeHotp
+		key.Counter = t.Counter
+	}
+
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// OtpAuth returns the slot as an otpauth url.
+func (t *Token) OtpAuth(slot Slot) (*otpauth.OtpAuth, error) {
+	key, err := t.Key(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := key.URL()
+	if err != nil {
+		return nil, err
+	}
+
+	return otpauth.NewKeyFromURL(u)
+}
@@ -0,0 +1,95 @@
+package token
+
+import (
+	"errors"
+	"otp/internal/clock"
+	"otp/pkg/otpauth"
+	"testing"
+	"time"
+)
+
+func TestFromURL(t *testing.T) {
+	otpToken, err := FromURL("otpauth://totp/RFC%206238:alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&algorithm=SHA1&digits=8&period=30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otpToken.Clock = clock.Fixed(time.Unix(1111111109, 0))
+
+	if otpToken.BankName != "RFC 6238" || otpToken.AccountId != "alice" || otpToken.Seed != "3132333435363738393031323334353637383930" {
+		t.Fatalf("wrong token : %+v", otpToken)
+	}
+
+	otp, err := otpToken.GenerateOtp1()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Authenticator apps prepend zeros
+	if otp != "07081804" {
+		t.Fatalf("expected 07081804, got %s", otp)
+	}
+
+	hotpToken, err := FromURL("otpauth://hotp/alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otp, err = hotpToken.NextOtp1()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otp != hotpVectors[5] || hotpToken.Counter != 6 {
+		t.Fatalf("expected %s and counter 6, got %s and %d", hotpVectors[5], otp, hotpToken.Counter)
+	}
+
+	if _, err := FromURL("otpauth://totp/alice?secret=1"); !errors.Is(err, otpauth.ErrInvalidSecret) {
+		t.Fatalf("expected ErrInvalidSecret, got %v", err)
+	}
+}
+
+func TestTokenKey(t *testing.T) {
+	otpToken := Token{
+		FirstOtpLength:  6,
+		SecondOtpLength: 8,
+		TimeInterval:    60000,
+		Algorithm:       otpauth.AlgorithmSHA512,
+		BankName:        "بانک سینا",
+		AccountId:       "6177236",
+		Seed:            "D2C3E15B8F90E747228BD733A885F6DCEB150968",
+		Profile:         &RFC4226,
+	}
+
+	otpAuth, err := otpToken.OtpAuth(Pin2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	converted, err := FromOtpAuth(otpAuth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if converted.Seed != otpToken.Seed || converted.FirstOtpLength != 8 || converted.TimeInterval != 60000 ||
+		converted.Algorithm != otpauth.AlgorithmSHA512 || converted.BankName != otpToken.BankName || converted.AccountId != otpToken.AccountId {
+		t.Fatalf("%s : wrong token %+v", otpAuth, converted)
+	}
+
+	if _, err := (&Token{Profile: &RefahLegacy, Seed: "AB", TimeInterval: 30000}).LossyKey(Pin1); !errors.Is(err, ErrNotPortable) {
+		t.Fatalf("expected ErrNotPortable, got %v", err)
+	}
+
+	// Codes of bank tokens append zeros, so they are only exported lossy
+	otpToken.Profile = nil
+	if _, err := otpToken.Key(Pin2); !errors.Is(err, ErrNotPortable) {
+		t.Fatalf("expected ErrNotPortable, got %v", err)
+	}
+
+	key, err := otpToken.LossyKey(Pin2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Digits != 8 || key.Period != 60 {
+		t.Fatalf("wrong key %+v", key)
+	}
+}
@@ -0,0 +1,175 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	ErrPinRequired = errors.New("token is protected by a pin")
+	ErrWrongPin    = errors.New("wrong pin")
+)
+
+const (
+	pinVerifierScheme     = "pbkdf2-sha256"
+	pinVerifierIterations = 200000
+	pinVerifierSaltSize   = 16
+
+	// maxPinVerifierIterations bounds the iterations read from a store, so
+	// an edited verifier can not make Unlock spin.
+	maxPinVerifierIterations = 10 * pinVerifierIterations
+)
+
+// IsPinProtected reports whether the token keeps only the server secret and
+// needs the pin to generate codes.
+func (t *Token) IsPinProtected() bool {
+	return len(t.ServerSecret) > 0
+}
+
+// ProtectWithPin removes the pin from the seeds of a token whose seed is the
+// server secret followed by the pin, as aras, saman and sina build it. The
+// token then needs Unlock before generating codes.
+//
+// With storeVerifier, a salted hash of the pin is kept so a wrong pin is
+// detected. It also lets anyone holding the token try pins offline, so it
+// is optional.
+func (t *Token) ProtectWithPin(pin string, storeVerifier bool) error {
+	if t.IsPinProtected() {
+		return errors.New("token is already protected by a pin")
+	}
+
+	if len(pin) == 0 {
+		return errors.New("pin is empty")
+	}
+
+	secret, err := stripPin(t.Seed, pin)
+	if err != nil {
+		return err
+	}
+
+	slots := map[Slot]SlotConfig{}
+	for name, config := range t.Slots {
+		if len(config.Seed) > 0 {
+			config.ServerSecret, err = stripPin(config.Seed, pin)
+			if err != nil {
+				return fmt.Errorf("slot %s : %w", name, err)
+			}
+			config.Seed = ""
+		}
+		slots[name] = config
+	}
+
+	verifier := ""
+	if storeVerifier {
+		verifier, err = newPinVerifier(pin)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.ServerSecret = secret
+	t.Seed = ""
+	t.PinVerifier = verifier
+	if len(slots) > 0 {
+		t.Slots = slots
+	}
+
+	return nil
+}
+
+// Unlock returns a copy of a pin protected token that generates codes with
+// the pin. Only the pin verifier, if stored, can tell a wrong pin; without it
+// a wrong pin generates wrong codes.
+func (t *Token) Unlock(pin string) (*Token, error) {
+	if !t.IsPinProtected() {
+		return nil, errors.New("token is not protected by a pin")
+	}
+
+	if len(t.PinVerifier) > 0 {
+		ok, err := checkPinVerifier(t.PinVerifier, pin)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrWrongPin
+		}
+	}
+
+	unlocked := *t
+	unlocked.Seed = t.ServerSecret + pin
+	unlocked.ServerSecret = ""
+	unlocked.PinVerifier = ""
+
+	if len(t.Slots) > 0 {
+		unlocked.Slots = map[Slot]SlotConfig{}
+		for name, config := range t.Slots {
+			if len(config.ServerSecret) > 0 {
+				config.Seed = config.ServerSecret + pin
+				config.ServerSecret = ""
+			}
+			unlocked.Slots[name] = config
+		}
+	}
+
+	return &unlocked, nil
+}
+
+func stripPin(seed, pin string) (string, error) {
+	if len(seed) <= len(pin) || !strings.HasSuffix(seed, pin) {
+		return "", errors.New("seed does not end with the pin")
+	}
+	return strings.TrimSuffix(seed, pin), nil
+}
+
+// newPinVerifier returns a salted hash of the pin in
+// pbkdf2-sha256$<iterations>$<salt>$<hash> format.
+func newPinVerifier(pin string) (string, error) {
+	salt := make([]byte, pinVerifierSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := pbkdf2.Key([]byte(pin), salt, pinVerifierIterations, sha256.Size, sha256.New)
+
+	return strings.Join([]string{
+		pinVerifierScheme,
+		strconv.Itoa(pinVerifierIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+func checkPinVerifier(verifier, pin string) (bool, error) {
+	parts := strings.Split(verifier, "$")
+	if len(parts) != 4 || parts[0] != pinVerifierScheme {
+		return false, errors.New("unknown pin verifier format")
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 || iterations > maxPinVerifierIterations {
+		return false, errors.New("invalid pin verifier iterations")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false, err
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, err
+	}
+	if len(key) != sha256.Size {
+		return false, errors.New("invalid pin verifier hash")
+	}
+
+	return subtle.ConstantTimeCompare(pbkdf2.Key([]byte(pin), salt, iterations, sha256.Size, sha256.New), key) == 1, nil
+}
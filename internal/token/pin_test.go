@@ -0,0 +1,124 @@
+package token
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"otp/internal/clock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPinVerifierIterations(t *testing.T) {
+	verifier, err := newPinVerifier("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := checkPinVerifier(verifier, "1234"); !ok || err != nil {
+		t.Fatalf("pin is not accepted : %v", err)
+	}
+
+	// An edited store must not make Unlock spin
+	parts := strings.Split(verifier, "$")
+	parts[1] = "1000000000000"
+	protected := Token{ServerSecret: "AB", PinVerifier: strings.Join(parts, "$")}
+	if _, err := protected.Unlock("1234"); err == nil || errors.Is(err, ErrWrongPin) {
+		t.Fatalf("expected invalid iterations, got %v", err)
+	}
+}
+
+func TestProtectWithPin(t *testing.T) {
+	const pin = "150968"
+	secret := strings.ToUpper(hex.EncodeToString([]byte("server secret")))
+
+	original := Token{
+		FirstOtpLength: 6,
+		TimeInterval:   60000,
+		Seed:           secret + pin,
+		Clock:          clock.Fixed(time.Unix(1652703737, 0)),
+	}
+	expected, err := original.GenerateOtp1()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, storeVerifier := range []bool{false, true} {
+		protected := original
+		if err := protected.ProtectWithPin(pin, storeVerifier); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := json.Marshal(&protected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), secret+pin) {
+			t.Fatalf("pin derived seed is saved : %s", data)
+		}
+		if storeVerifier == (len(protected.PinVerifier) == 0) {
+			t.Fatalf("verifier is not kept as asked : %s", data)
+		}
+
+		if _, err := protected.GenerateOtp1(); !errors.Is(err, ErrPinRequired) {
+			t.Fatalf("expected ErrPinRequired, got %v", err)
+		}
+
+		unlocked, err := protected.Unlock(pin)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		otp, err := unlocked.GenerateOtp1()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if otp != expected {
+			t.Fatalf("expected %s, got %s", expected, otp)
+		}
+
+		_, err = protected.Unlock("000000")
+		if storeVerifier && !errors.Is(err, ErrWrongPin) {
+			t.Fatalf("expected ErrWrongPin, got %v", err)
+		}
+		if !storeVerifier && err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProtectSlotsWithPin(t *testing.T) {
+	const pin = "1234"
+
+	otpToken := Token{TimeInterval: 30000, Seed: "AB" + pin}
+	otpToken.SetSlot(Pin2, SlotConfig{Seed: "CD" + pin, Length: 8})
+	otpToken.SetSlot(Modern, SlotConfig{Length: 6})
+
+	if err := otpToken.ProtectWithPin(pin, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if otpToken.ServerSecret != "AB" || otpToken.Slots[Pin2].ServerSecret != "CD" || len(otpToken.Slots[Pin2].Seed) != 0 {
+		t.Fatalf("pin is not removed : %+v", otpToken)
+	}
+
+	unlocked, err := otpToken.Unlock(pin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for slot, seed := range map[Slot]string{Pin2: "CD" + pin, Modern: "AB" + pin} {
+		config, err := unlocked.Slot(slot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Seed != seed {
+			t.Errorf("%s : expected seed %s, got %s", slot, seed, config.Seed)
+		}
+	}
+
+	if err := (&Token{Seed: "AB1234"}).ProtectWithPin("9999", false); err == nil {
+		t.Fatal("a seed without the pin should not be protected")
+	}
+}
@@ -0,0 +1,148 @@
+package token
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// Padding is the side short codes are padded with zeros on.
+type Padding string
+
+const (
+	// PadAppend appends zeros, as the bank apps ported here do. It is the
+	// default to keep the codes of saved tokens.
+	PadAppend Padding = "append"
+
+	// PadPrepend prepends zeros, as RFC 4226 does.
+	PadPrepend Padding = "prepend"
+)
+
+const defaultMask = 0x7FFFFFFF
+
+// Profile describes how the hmac of a token is turned into a code.
+// The zero value is the legacy format of this package.
+type Profile struct {
+	Padding Padding `json:"padding,omitempty"`
+
+	// Mask is applied to the truncated hmac. Zero means 0x7FFFFFFF.
+	Mask uint32 `json:"mask,omitempty"`
+
+	// Digits is the code length used when the token does not set one.
+	Digits int `json:"digits,omitempty"`
+}
+
+// RFC4226 is the format of standard authenticator apps.
+var RFC4226 = Profile{Padding: PadPrepend}
+
+// RefahLegacy is the format of the older Refah app, ported from its code. No
+// code shown by the app is known, so it is not a built-in profile.
+var RefahLegacy = Profile{Padding: PadAppend, Mask: 1591523992, Digits: 7}
+
+// Built-in profiles by bank name, see bankKey. Only banks with a code shown
+// by their app are listed, and every one of them is checked against it in
+// the tests. Other banks use the legacy profile. None of the known codes
+// start with a zero, so padding follows the ported apps.
+var profiles = map[string]Profile{
+	"eghtesad novin":  {Padding: PadAppend, Digits: 6},
+	"eghtesade novin": {Padding: PadAppend, Digits: 6},
+	"tejarat":         {Padding: PadAppend, Digits: 8},
+}
+
+// bankKey returns the key of a bank name in profiles. Providers name banks
+// differently, like "Eghtesad Novin" and "Eghtesade Novin Bank" of Aras, so
+// case, spaces and a trailing "bank" are ignored.
+func bankKey(bankName string) string {
+	key := strings.Join(strings.Fields(strings.ToLower(bankName)), " ")
+	return strings.TrimSpace(strings.TrimSuffix(key, " bank"))
+}
+
+// ProfileOf returns the built-in profile of a bank. Banks without one, see
+// the package doc, get the legacy profile and false.
+func ProfileOf(bankName string) (Profile, bool) {
+	profile, ok := profiles[bankKey(bankName)]
+	return profile, ok
+}
+
+func (p Profile) mask() uint32 {
+	if p.Mask == 0 {
+		return defaultMask
+	}
+	return p.Mask
+}
+
+// Format truncates an hmac sum to a code of otpLength digits as described in
+// RFC 4226, using the mask and padding of the profile.
+func (p Profile) Format(sum []byte, otpLength int) string {
+	var buf [20]byte
+	return string(p.appendCode(buf[:0], sum, otpLength))
+}
+
+// pow10 holds the powers of ten that fit in an uint64.
+var pow10 = func() (p [20]uint64) {
+	p[0] = 1
+	for i := 1; i < len(p); i++ {
+		p[i] = p[i-1] * 10
+	}
+	return
+}()
+
+func (p Profile) appendCode(dst, sum []byte, otpLength int) []byte {
+	if otpLength <= 0 {
+		otpLength = p.Digits
+	}
+
+	offset := int(sum[len(sum)-1] & 15)
+	otpNumber := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & p.mask())
+	if otpLength < len(pow10) {
+		otpNumber %= pow10[otpLength]
+	}
+
+	// Digits of the number, written from the end of a buffer
+	var digits [20]byte
+	i := len(digits)
+	for {
+		i--
+		digits[i] = byte('0' + otpNumber%10)
+		otpNumber /= 10
+		if otpNumber == 0 {
+			break
+		}
+	}
+
+	padding := otpLength - (len(digits) - i)
+	if p.Padding == PadPrepend {
+		for ; padding > 0; padding-- {
+			dst = append(dst, '0')
+		}
+	}
+
+	dst = append(dst, digits[i:]...)
+
+	for ; padding > 0; padding-- {
+		dst = append(dst, '0')
+	}
+
+	return dst
+}
+
+// profile returns the profile of the token, falling back to the built-in
+// profile of its bank.
+func (t *Token) profile() Profile {
+	if t.Profile != nil {
+		return *t.Profile
+	}
+	profile, _ := ProfileOf(t.BankName)
+	return profile
+}
+
+// digits returns otpLength, or the default code length of the token if it
+// is not set.
+func (t *Token) digits(otpLength int) int {
+	if otpLength > 0 {
+		return otpLength
+	}
+	if t.OtpLength > 0 {
+		return t.OtpLength
+	}
+	return t.profile().Digits
+}
@@ -0,0 +1,160 @@
+package token
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"otp/internal/clock"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/unicode/utf32"
+)
+
+func TestProfileFormat(t *testing.T) {
+	sum := func(truncated uint32) []byte {
+		b := make([]byte, 20)
+		b[0], b[1], b[2], b[3] = byte(truncated>>24), byte(truncated>>16), byte(truncated>>8), byte(truncated)
+		return b
+	}
+
+	tests := []struct {
+		profile Profile
+		sum     []byte
+		digits  int
+		otp     string
+	}{
+		{Profile{}, sum(0xFFFFFFFF), 6, "483647"},
+		{Profile{Mask: 1591523992}, sum(0xFFFFFFFF), 7, "1523992"},
+		{Profile{}, sum(42), 6, "420000"},
+		{Profile{Padding: PadAppend}, sum(42), 6, "420000"},
+		{RFC4226, sum(42), 6, "000042"},
+		{Profile{Digits: 8}, sum(42), 0, "42000000"},
+	}
+
+	for _, test := range tests {
+		otp := test.profile.Format(test.sum, test.digits)
+		if otp != test.otp {
+			t.Errorf("%+v : expected %s, got %s", test.profile, test.otp, otp)
+		}
+	}
+}
+
+// Codes shown by the bank apps
+func TestBankProfiles(t *testing.T) {
+	hamrazKey, err := utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM).NewEncoder().Bytes([]byte("1003450364" + "2523C3EEAC8052242627"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hamrazSeed := sha1.Sum(hamrazKey)
+
+	tests := []struct {
+		bankName  string
+		seed      string
+		interval  int
+		timestamp int64
+		otp       string
+	}{
+		{"Eghtesad Novin", "9D5D96988C8D267DD4FD4B5AF7EFB394A493B85A", 60000, 1652703737, "778024"},
+		{"Eghtesade Novin Bank", "9D5D96988C8D267DD4FD4B5AF7EFB394A493B85A", 60000, 1652703737, "778024"},
+		{"Tejarat", strings.ToUpper(hex.EncodeToString(hamrazSeed[:])), 30000, 1597087430, "63212052"},
+	}
+
+	// Every built-in profile is checked against a code of its app
+	checked := map[string]bool{}
+	for _, test := range tests {
+		checked[bankKey(test.bankName)] = true
+	}
+	for key := range profiles {
+		if !checked[key] {
+			t.Errorf("%s : profile has no code of the bank app", key)
+		}
+	}
+
+	for _, test := range tests {
+		otpToken := Token{
+			TimeInterval: test.interval,
+			BankName:     test.bankName,
+			Seed:         test.seed,
+			Clock:        clock.Fixed(time.Unix(test.timestamp, 0)),
+		}
+
+		otp, err := otpToken.GenerateOtp1()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if otp != test.otp {
+			t.Errorf("%s : expected %s, got %s", test.bankName, test.otp, otp)
+		}
+	}
+}
+
+func TestProfileOf(t *testing.T) {
+	tests := []struct {
+		bankName string
+		profile  Profile
+		ok       bool
+	}{
+		{"Tejarat", profiles["tejarat"], true},
+		{" eghtesade  novin BANK", profiles["eghtesade novin"], true},
+		{"Sina Bank", Profile{}, false},
+		{"Ansar", Profile{}, false},
+		{"Refah", Profile{}, false},
+	}
+
+	for _, test := range tests {
+		if profile, ok := ProfileOf(test.bankName); profile != test.profile || ok != test.ok {
+			t.Errorf("%q : expected %+v %v, got %+v %v", test.bankName, test.profile, test.ok, profile, ok)
+		}
+	}
+}
+
+// RFC 6238 appendix B has a code with a leading zero
+func TestPrependPadding(t *testing.T) {
+	otpToken := Token{
+		FirstOtpLength: 8,
+		TimeInterval:   30000,
+		Seed:           hex.EncodeToString([]byte("12345678901234567890")),
+		Clock:          clock.Fixed(time.Unix(1111111109, 0)),
+		Profile:        &RFC4226,
+	}
+
+	otp, err := otpToken.GenerateOtp1()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otp != "07081804" {
+		t.Fatalf("expected 07081804, got %s", otp)
+	}
+
+	otpToken.Profile = nil
+	otp, err = otpToken.GenerateOtp1()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otp != "70818040" {
+		t.Fatalf("legacy padding : expected 70818040, got %s", otp)
+	}
+}
+
+func TestProfileJson(t *testing.T) {
+	otpToken := Token{Profile: &Profile{Padding: PadPrepend, Mask: 1591523992}}
+
+	data, err := json.Marshal(&otpToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Token
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Profile == nil || *decoded.Profile != *otpToken.Profile {
+		t.Fatalf("profile is not kept : %s", data)
+	}
+}
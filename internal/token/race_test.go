@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package token
+
+// raceEnabled is set when the tests run with the race detector, which makes
+// sync.Pool allocate.
+const raceEnabled = true
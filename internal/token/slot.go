@@ -0,0 +1,204 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"otp/pkg/otpauth"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Slot names one of the passwords a token generates.
+type Slot string
+
+const (
+	Pin1   Slot = "pin1"
+	Pin2   Slot = "pin2"
+	Modern Slot = "modern"
+)
+
+var ErrUnknownSlot = errors.New("token has no such slot")
+
+// SlotConfig holds the parameters of a single password. Zero fields fall back
+// to the fields of the token. SHA1 is the zero Algorithm, so HasAlgorithm
+// tells that the slot uses Algorithm even if it is SHA1.
+type SlotConfig struct {
+	Seed string `json:"seed,omitempty"`
+
+	// TimeInterval is the time step in milliseconds, as in Token.
+	TimeInterval int `json:"timeIntervalMilliseconds,omitempty"`
+
+	Length       int               `json:"length,omitempty"`
+	Algorithm    otpauth.Algorithm `json:"algorithm,omitempty"`
+	HasAlgorithm bool              `json:"hasAlgorithm,omitempty"`
+	ServerSecret string            `json:"serverSecret,omitempty"`
+}
+
+// period returns the time step of the slot.
+func (c SlotConfig) period() time.Duration {
+	return time.Duration(c.TimeInterval/1000) * time.Second
+}
+
+// SlotForChannel returns the slot of a channel name or otp type used by the
+// bank apps, like CARD_SECOND or CARD_FIRST_PASSWORD.
+func SlotForChannel(name string) (Slot, bool) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "CARD_FIRST", "CARD_FIRST_PASSWORD":
+		return Pin1, true
+	case "CARD_SECOND", "CARD_SECOND_PASSWORD":
+		return Pin2, true
+	case "MODERN", "MODERN_FIRST_PASSWORD":
+		return Modern, true
+	}
+	return "", false
+}
+
+// Slot returns the parameters of the slot. Tokens without slots generate
+// every slot from their own fields, with the length of the slot. Every field
+// of the returned config is set, so it can be kept as the slot of another
+// token.
+func (t *Token) Slot(slot Slot) (SlotConfig, error) {
+	config := SlotConfig{
+		Seed:         t.Seed,
+		TimeInterval: t.TimeInterval,
+		Algorithm:    t.Algorithm,
+		HasAlgorithm: true,
+	}
+
+	if len(t.Slots) > 0 {
+		c, ok := t.Slots[slot]
+		if !ok {
+			return SlotConfig{}, fmt.Errorf("%w : %s", ErrUnknownSlot, slot)
+		}
+
+		if len(c.Seed) > 0 {
+			config.Seed = c.Seed
+		}
+		if c.TimeInterval > 0 {
+			config.TimeInterval = c.TimeInterval
+		}
+		if c.HasAlgorithm || c.Algorithm != otpauth.AlgorithmSHA1 {
+			config.Algorithm = c.Algorithm
+		}
+		config.Length = c.Length
+	}
+
+	if config.Length == 0 {
+		switch slot {
+		case Pin1:
+			config.Length = t.FirstOtpLength
+		case Pin2:
+			config.Length = t.SecondOtpLength
+		case Modern:
+			config.Length = t.OtpLength
+		default:
+			if len(t.Slots) == 0 {
+				return SlotConfig{}, fmt.Errorf("%w : %s", ErrUnknownSlot, slot)
+			}
+		}
+	}
+
+	if len(config.Seed) == 0 && t.IsPinProtected() {
+		return SlotConfig{}, ErrPinRequired
+	}
+
+	config.Length = t.digits(config.Length)
+	return config, nil
+}
+
+// SetSlot sets the parameters of the slot. A token that had no slots keeps
+// generating only the slots set afterwards.
+func (t *Token) SetSlot(slot Slot, config SlotConfig) {
+	if t.Slots == nil {
+		t.Slots = map[Slot]SlotConfig{}
+	}
+	t.Slots[slot] = config
+}
+
+// BindSlot marks the token as generating only the given slot, with its
+// current parameters. It is used for activations that return a token for a
+// single password.
+func (t *Token) BindSlot(slot Slot) error {
+	config, err := t.Slot(slot)
+	if err != nil {
+		return err
+	}
+
+	t.Slots = map[Slot]SlotConfig{slot: config}
+	return nil
+}
+
+// slotsOfLength returns the distinct configs of the slots that generate
+// codes of the given length.
+func (t *Token) slotsOfLength(otpLength int) ([]SlotConfig, error) {
+	var configs []SlotConfig
+	for _, name := range t.SlotNames() {
+		config, err := t.Slot(name)
+		if err != nil {
+			return nil, err
+		}
+		if config.Length != otpLength {
+			continue
+		}
+
+		duplicate := false
+		for _, c := range configs {
+			if c == config {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			configs = append(configs, config)
+		}
+	}
+	return configs, nil
+}
+
+// SlotNames returns the slots the token generates, sorted by name.
+func (t *Token) SlotNames() []Slot {
+	var names []Slot
+	if len(t.Slots) > 0 {
+		for name := range t.Slots {
+			names = append(names, name)
+		}
+	} else {
+		if t.FirstOtpLength > 0 {
+			names = append(names, Pin1)
+		}
+		if t.SecondOtpLength > 0 {
+			names = append(names, Pin2)
+		}
+		if t.OtpLength > 0 {
+			names = append(names, Modern)
+		}
+		if len(names) == 0 {
+			names = append(names, Pin1)
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+// Merge adds the slots of another activation of the same account to the
+// token. Slots the token already has are replaced.
+func (t *Token) Merge(other *Token) error {
+	slots := map[Slot]SlotConfig{}
+	for _, from := range []*Token{t, other} {
+		for _, name := range from.SlotNames() {
+			config, err := from.Slot(name)
+			if err != nil {
+				return err
+			}
+			slots[name] = config
+		}
+	}
+
+	t.Slots = slots
+	return nil
+}
@@ -0,0 +1,205 @@
+package token
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"otp/internal/clock"
+	"otp/pkg/otpauth"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	rfcSeed1   = hex.EncodeToString([]byte(strings.Repeat("1234567890", 2)))
+	rfcSeed256 = hex.EncodeToString([]byte(strings.Repeat("1234567890", 3) + "12"))
+)
+
+func TestLegacySlots(t *testing.T) {
+	var otpToken Token
+	err := json.Unmarshal([]byte(`{"firstOtpLength":6,"secondOtpLength":8,"otpLength":0,"otpGenerationPeriodInSeconds":30000,"Seed":"`+rfcSeed1+`"}`), &otpToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otpToken.Clock = clock.Fixed(time.Unix(59, 0))
+
+	names := otpToken.SlotNames()
+	if len(names) != 2 || names[0] != Pin1 || names[1] != Pin2 {
+		t.Fatalf("expected pin1 and pin2, got %v", names)
+	}
+
+	otp1, err := otpToken.GenerateOtp1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otp2, err := otpToken.GenerateOtp2()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otp1 != "287082" || otp2 != "94287082" {
+		t.Fatalf("expected 287082 and 94287082, got %s and %s", otp1, otp2)
+	}
+}
+
+func TestSlots(t *testing.T) {
+	otpToken := Token{
+		FirstOtpLength: 6,
+		TimeInterval:   30000,
+		Seed:           rfcSeed1,
+		Clock:          clock.Fixed(time.Unix(1111111111, 0)),
+		Profile:        &RFC4226,
+	}
+	otpToken.SetSlot(Pin1, SlotConfig{})
+	otpToken.SetSlot(Pin2, SlotConfig{Seed: rfcSeed256, Length: 8, Algorithm: otpauth.AlgorithmSHA256})
+
+	data, err := json.Marshal(&otpToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Token
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	decoded.Clock = otpToken.Clock
+
+	otp1, err := decoded.GenerateOtp1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otp2, err := decoded.GenerateOtp2()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otp1 != "050471" || otp2 != "67062674" {
+		t.Fatalf("expected 050471 and 67062674, got %s and %s", otp1, otp2)
+	}
+
+	if _, err := decoded.Code(Modern); !errors.Is(err, ErrUnknownSlot) {
+		t.Fatalf("expected ErrUnknownSlot, got %v", err)
+	}
+
+	step, err := newVerifier(t, 1).Verify(&decoded, "67062674")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step != 1111111111/30 {
+		t.Fatalf("expected step %d, got %d", 1111111111/30, step)
+	}
+}
+
+func TestBindAndMerge(t *testing.T) {
+	second := Token{
+		FirstOtpLength:  8,
+		SecondOtpLength: 8,
+		OtpLength:       8,
+		TimeInterval:    30000,
+		Seed:            rfcSeed1,
+		Clock:           clock.Fixed(time.Unix(59, 0)),
+	}
+	if err := second.BindSlot(Pin2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := second.GenerateOtp1(); !errors.Is(err, ErrUnknownSlot) {
+		t.Fatalf("expected ErrUnknownSlot, got %v", err)
+	}
+
+	modern := Token{
+		OtpLength:    8,
+		TimeInterval: 60000,
+		Algorithm:    otpauth.AlgorithmSHA256,
+		Seed:         rfcSeed256,
+	}
+	if err := modern.BindSlot(Modern); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := second.Merge(&modern); err != nil {
+		t.Fatal(err)
+	}
+
+	names := second.SlotNames()
+	if len(names) != 2 || names[0] != Modern || names[1] != Pin2 {
+		t.Fatalf("expected modern and pin2, got %v", names)
+	}
+
+	otp2, err := second.GenerateOtp2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otp2 != "94287082" {
+		t.Fatalf("expected 94287082, got %s", otp2)
+	}
+
+	config, err := second.Slot(Modern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Seed != rfcSeed256 || config.TimeInterval != 60000 || config.Algorithm != otpauth.AlgorithmSHA256 {
+		t.Fatalf("modern slot is not kept : %+v", config)
+	}
+}
+
+func TestSlotSHA1(t *testing.T) {
+	otpToken := Token{
+		FirstOtpLength: 8,
+		TimeInterval:   30000,
+		Algorithm:      otpauth.AlgorithmSHA256,
+		Seed:           rfcSeed256,
+		Clock:          clock.Fixed(time.Unix(59, 0)),
+	}
+
+	sha1Token := Token{SecondOtpLength: 8, TimeInterval: 30000, Seed: rfcSeed1}
+	if err := sha1Token.BindSlot(Pin2); err != nil {
+		t.Fatal(err)
+	}
+	if err := otpToken.Merge(&sha1Token); err != nil {
+		t.Fatal(err)
+	}
+	otpToken.SetSlot(Modern, SlotConfig{Seed: rfcSeed1, Length: 8, HasAlgorithm: true})
+
+	// The slots are kept as SHA1 after the token is saved and loaded
+	encoded, err := json.Marshal(otpToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Token
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	decoded.Clock = otpToken.Clock
+
+	for slot, expected := range map[Slot]string{Pin1: "46119246", Pin2: "94287082", Modern: "94287082"} {
+		code, err := decoded.Code(slot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code.Value != expected {
+			t.Errorf("%s : expected %s, got %s", slot, expected, code.Value)
+		}
+	}
+}
+
+func TestSlotForChannel(t *testing.T) {
+	tests := map[string]Slot{
+		"CARD_FIRST_PASSWORD":   Pin1,
+		"CARD_SECOND":           Pin2,
+		"CARD_SECOND_PASSWORD":  Pin2,
+		"MODERN":                Modern,
+		"MODERN_FIRST_PASSWORD": Modern,
+	}
+
+	for channel, expected := range tests {
+		if slot, ok := SlotForChannel(channel); !ok || slot != expected {
+			t.Errorf("%s : expected %s, got %s", channel, expected, slot)
+		}
+	}
+
+	if _, ok := SlotForChannel("CARD"); ok {
+		t.Error("CARD should have no slot")
+	}
+}
@@ -1,96 +1,164 @@
+// Package token generates the codes of bank tokens.
+//
+// The hmac of a token is turned into a code by a Profile. Built-in profiles
+// exist only for banks with codes shown by their app, which the tests check:
+// Eghtesad Novin and Tejarat. No app codes are known for the other banks
+// with ported code, so they have no profile and ProfileOf reports it:
+//
+//   - Refah, whose older app mask is kept as RefahLegacy
+//   - Saman and Sina
+//   - Apan and Rima
+//   - the other banks of Aras: Dey, Hekmat Iranian, Ansar and Mehr
+//
+// Their tokens use the legacy profile, which appends zeros to short codes as
+// the ported apps do.
 package token
 
 import (
-	"crypto/hmac"
-	"crypto/sha1"
 	"encoding/base32"
-	"encoding/binary"
 	"encoding/hex"
-	"fmt"
-	"hash"
-	"math"
+	"otp/internal/clock"
 	"otp/pkg/otpauth"
+	"sync/atomic"
 	"time"
 )
 
+const (
+	TypeTotp = "totp"
+	TypeHotp = "hotp"
+)
+
 type Token struct {
-	FirstOtpLength  int    `json:"firstOtpLength"`
-	SecondOtpLength int    `json:"secondOtpLength"`
-	OtpLength       int    `json:"otpLength"`
-	SecretKey       string `json:"secretKey,omitempty"`
-	TimeInterval    int    `json:"otpGenerationPeriodInSeconds"`
+	FirstOtpLength  int                 `json:"firstOtpLength"`
+	SecondOtpLength int                 `json:"secondOtpLength"`
+	OtpLength       int                 `json:"otpLength"`
+	SecretKey       string              `json:"secretKey,omitempty"`
+	TimeInterval    int                 `json:"otpGenerationPeriodInSeconds"`
+	Algorithm       otpauth.Algorithm   `json:"algorithm,omitempty"`
+	Type            string              `json:"type,omitempty"`
+	Counter         uint64              `json:"counter,omitempty"`
+	ServerHost      string              `json:"serverHost,omitempty"`
+	UseServerTime   bool                `json:"useServerTime,omitempty"`
+	ServerOffset    time.Duration       `json:"serverOffsetNanoseconds,omitempty"`
+	Profile         *Profile            `json:"profile,omitempty"`
+	Slots           map[Slot]SlotConfig `json:"slots,omitempty"`
+	ServerSecret    string              `json:"serverSecret,omitempty"`
+	PinVerifier     string              `json:"pinVerifier,omitempty"`
+	Clock           clock.Clock         `json:"-"`
+	generators      atomic.Value
 	BankName        string
 	AccountId       string
 	Seed            string
 }
 
 func (t *Token) GenerateOtp1() (string, error) {
-	return t.generateOtp(t.Seed, t.TimeInterval/1000, t.FirstOtpLength, sha1.New, time.Now().Unix())
+	return t.otp(Pin1, t.now())
 }
 
 func (t *Token) GenerateOtp2() (string, error) {
-	return t.generateOtp(t.Seed, t.TimeInterval/1000, t.SecondOtpLength, sha1.New, time.Now().Unix())
+	return t.otp(Pin2, t.now())
 }
 
+// Deprecated: Set Clock to generate codes of another time.
 func (t *Token) GenerateOtp1WithTimestamp(timestamp int64) (string, error) {
-	return t.generateOtp(t.Seed, t.TimeInterval/1000, t.FirstOtpLength, sha1.New, timestamp)
+	return t.otp(Pin1, time.Unix(timestamp, 0))
 }
 
+// Deprecated: Set Clock to generate codes of another time.
 func (t *Token) GenerateOtp2WithTimestamp(timestamp int64) (string, error) {
-	return t.generateOtp(t.Seed, t.TimeInterval/1000, t.SecondOtpLength, sha1.New, timestamp)
+	return t.otp(Pin2, time.Unix(timestamp, 0))
 }
 
-func (t *Token) generateOtp(seed string, interval int, otpLength int, hashFunction func() hash.Hash, timestamp int64) (string, error) {
-	key, err := hex.DecodeString(seed)
+func (t *Token) otp(slot Slot, now time.Time) (string, error) {
+	code, err := t.CodeAt(slot, now)
 	if err != nil {
 		return "", err
 	}
+	return code.Value, nil
+}
+
+// now returns the current time of the token clock. Without a clock, tokens
+// that use server time follow the estimated clock of their bank server, or
+// the offset that is kept in the token when there is no recent estimate.
+func (t *Token) now() time.Time {
+	if t.Clock == nil && t.UseServerTime && len(t.ServerHost) > 0 {
+		if offset, ok := clock.HostOffset(t.ServerHost); ok {
+			return clock.System.Now().Add(offset)
+		}
+		return clock.System.Now().Add(t.ServerOffset)
+	}
+	return clock.Now(t.Clock)
+}
+
+// Now returns the current time of the token clock, which codes are
+// generated at.
+func (t *Token) Now() time.Time {
+	return t.now()
+}
+
+// SyncServerTime makes a token of a bank server follow the server clock and
+// keeps the estimated offset of the server in the token, so it is still
+// used after the token is saved and loaded. It reports whether the server
+// has a recent estimate.
+func (t *Token) SyncServerTime() bool {
+	if len(t.ServerHost) == 0 {
+		return false
+	}
 
-	now := make([]byte, 8)
-	binary.BigEndian.PutUint64(now, uint64(timestamp/int64(interval)))
-
-	h := hmac.New(hashFunction, key)
-	h.Write(now)
-	hmacBytes := h.Sum(nil)
-
-	offset := int(hmacBytes[len(hmacBytes)-1] & 15)
-	otpNumber := binary.BigEndian.Uint32(hmacBytes[offset : offset+4])
-	otpNumber &= 0x7FFFFFFF
-	otpNumber %= uint32(math.Pow10(otpLength))
-	otp := fmt.Sprintf("%d", otpNumber)
-	// TODO: Should zeros be appended or prepended?
-	for len(otp) < otpLength {
-		otp += "0"
+	t.UseServerTime = true
+	offset, ok := clock.HostOffset(t.ServerHost)
+	if ok {
+		t.ServerOffset = offset
 	}
-	return otp, nil
+	return ok
+}
+
+// IsHotp reports whether the token is counter based.
+func (t *Token) IsHotp() bool {
+	return t.Type == TypeHotp
+}
+
+// counter returns the moving factor of the otp. It is the time step of the
+// timestamp for time based tokens and the stored counter for hotp tokens.
+func (t *Token) counter(timestamp int64, interval int) uint64 {
+	if t.IsHotp() {
+		return t.Counter
+	}
+	return uint64(timestamp / int64(interval/1000))
 }
 
 func (t *Token) GeneralOtp1UrlFromToken() (string, error) {
-	return t.generalOtpUrlWithUsername(t.BankName, t.AccountId, t.FirstOtpLength)
+	return t.GeneralOtpUrl(Pin1, t.BankName, t.AccountId)
 }
 
 func (t *Token) GeneralOtp2UrlFromToken() (string, error) {
-	return t.generalOtpUrlWithUsername(t.BankName, t.AccountId, t.SecondOtpLength)
+	return t.GeneralOtpUrl(Pin2, t.BankName, t.AccountId)
 }
 
 func (t *Token) GeneralOtp1Url(title string) (string, error) {
-	return t.generalOtpUrlWithUsername(title, "", t.FirstOtpLength)
+	return t.GeneralOtpUrl(Pin1, title, "")
 }
 
 func (t *Token) GeneralOtp1UrlWithUsername(title, username string) (string, error) {
-	return t.generalOtpUrlWithUsername(title, username, t.FirstOtpLength)
+	return t.GeneralOtpUrl(Pin1, title, username)
 }
 
 func (t *Token) GeneralOtp2Url(title string) (string, error) {
-	return t.generalOtpUrlWithUsername(title, "", t.SecondOtpLength)
+	return t.GeneralOtpUrl(Pin2, title, "")
 }
 
 func (t *Token) GeneralOtp2UrlWithUsername(title, username string) (string, error) {
-	return t.generalOtpUrlWithUsername(title, username, t.SecondOtpLength)
+	return t.GeneralOtpUrl(Pin2, title, username)
 }
 
-func (t *Token) generalOtpUrlWithUsername(title, username string, digits int) (string, error) {
-	key, err := hex.DecodeString(t.Seed)
+// GeneralOtpUrl returns the otpauth url of the slot for authenticator apps.
+func (t *Token) GeneralOtpUrl(slot Slot, title, username string) (string, error) {
+	config, err := t.Slot(slot)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := hex.DecodeString(config.Seed)
 	if err != nil {
 		return "", err
 	}
@@ -103,8 +171,15 @@ func (t *Token) generalOtpUrlWithUsername(title, username string, digits int) (s
 	}
 
 	otpAuth.SetAccountName(username)
-	otpAuth.SetDigit(digits)
-	otpAuth.SetPeriod(t.TimeInterval / 1000)
+	otpAuth.SetDigit(config.Length)
+	otpAuth.SetAlgorithm(config.Algorithm)
+
+	if t.IsHotp() {
+		otpAuth.SetType(TypeHotp)
+		otpAuth.SetCounter(int(t.Counter))
+	} else {
+		otpAuth.SetPeriod(config.TimeInterval / 1000)
+	}
 
 	return otpAuth.String(), nil
 }
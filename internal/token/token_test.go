@@ -0,0 +1,117 @@
+package token
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"otp/internal/clock"
+	"otp/pkg/otpauth"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test vectors of RFC 6238 appendix B
+func TestAlgorithms(t *testing.T) {
+	seed := "1234567890"
+	tests := []struct {
+		algorithm otpauth.Algorithm
+		seed      string
+		timestamp int64
+		otp       string
+	}{
+		{otpauth.AlgorithmSHA1, strings.Repeat(seed, 2), 59, "94287082"},
+		{otpauth.AlgorithmSHA256, strings.Repeat(seed, 3) + "12", 59, "46119246"},
+		{otpauth.AlgorithmSHA512, strings.Repeat(seed, 6) + "1234", 59, "90693936"},
+		{otpauth.AlgorithmSHA1, strings.Repeat(seed, 2), 1111111111, "14050471"},
+		{otpauth.AlgorithmSHA256, strings.Repeat(seed, 3) + "12", 1111111111, "67062674"},
+		{otpauth.AlgorithmSHA512, strings.Repeat(seed, 6) + "1234", 1111111111, "99943326"},
+	}
+
+	for _, test := range tests {
+		otpToken := Token{
+			FirstOtpLength: 8,
+			TimeInterval:   30000,
+			Algorithm:      test.algorithm,
+			Seed:           hex.EncodeToString([]byte(test.seed)),
+			Clock:          clock.Fixed(time.Unix(test.timestamp, 0)),
+		}
+
+		otp, err := otpToken.GenerateOtp1()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if otp != test.otp {
+			t.Errorf("%s at %d : expected %s, got %s", test.algorithm, test.timestamp, test.otp, otp)
+		}
+	}
+}
+
+func TestAlgorithmJson(t *testing.T) {
+	var otpToken Token
+	err := json.Unmarshal([]byte(`{"otpGenerationPeriodInSeconds":60000,"algorithm":"SHA256"}`), &otpToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otpToken.Algorithm != otpauth.AlgorithmSHA256 {
+		t.Fatalf("expected SHA256, got %s", otpToken.Algorithm)
+	}
+
+	otpToken.Algorithm = otpauth.AlgorithmSHA1
+	data, err := json.Marshal(otpToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), "algorithm") {
+		t.Fatalf("default algorithm should be omitted : %s", data)
+	}
+
+	url, err := (&Token{Seed: "3132", Algorithm: otpauth.AlgorithmSHA512}).GeneralOtp1Url("bank")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(url, "algorithm=SHA512") {
+		t.Fatalf("algorithm is missing in %s", url)
+	}
+}
+
+func TestServerTime(t *testing.T) {
+	now := time.Now()
+	clock.Observe("server-time.test", now.Add(90*time.Second), 0, now, now)
+
+	otpToken := Token{ServerHost: "server-time.test"}
+	if offset := otpToken.now().Sub(time.Now()); offset > time.Second || offset < -time.Second {
+		t.Fatalf("server time is used without UseServerTime : %v", offset)
+	}
+
+	if !otpToken.SyncServerTime() || !otpToken.UseServerTime || otpToken.ServerOffset != 90*time.Second {
+		t.Fatalf("offset is not kept : %+v", otpToken)
+	}
+
+	if offset := otpToken.now().Sub(time.Now()); offset < 89*time.Second || offset > 91*time.Second {
+		t.Fatalf("expected the estimated offset, got %v", offset)
+	}
+
+	// A loaded token has no estimate of its host but keeps its offset
+	encoded, err := json.Marshal(Token{ServerHost: "stored-time.test", UseServerTime: true, ServerOffset: -time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded Token
+	if err := json.Unmarshal(encoded, &loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	if offset := loaded.now().Sub(time.Now()); offset < -61*time.Second || offset > -59*time.Second {
+		t.Fatalf("expected the stored offset, got %v", offset)
+	}
+
+	loaded.Clock = clock.Fixed(time.Unix(59, 0))
+	if loaded.now().Unix() != 59 {
+		t.Fatal("clock of the token is not preferred over server time")
+	}
+}
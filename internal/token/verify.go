@@ -0,0 +1,157 @@
+package token
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+)
+
+var (
+	ErrInvalidCode  = errors.New("invalid code")
+	ErrReplayedCode = errors.New("code is already used")
+	ErrInvalidSkew  = errors.New("skew is negative")
+)
+
+// ReplayStore remembers the time steps that are already used by each token.
+type ReplayStore interface {
+	// Use marks the step as used for the key. It returns false if the step
+	// can not be used anymore.
+	Use(key string, step uint64) (bool, error)
+}
+
+// MemoryReplayStore keeps the last used step of every key in memory.
+// Steps before the last used one are refused too, as RFC 6238 recommends.
+type MemoryReplayStore struct {
+	mu    sync.Mutex
+	steps map[string]uint64
+}
+
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{steps: map[string]uint64{}}
+}
+
+func (s *MemoryReplayStore) Use(key string, step uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.steps[key]; ok && step <= last {
+		return false, nil
+	}
+
+	s.steps[key] = step
+	return true, nil
+}
+
+// Verifier checks codes generated by a token.
+type Verifier struct {
+	// Skew is the number of steps accepted before and after the current one.
+	// For hotp tokens only the steps after the counter are checked.
+	Skew int
+
+	// Store refuses codes that are used before. It can be nil.
+	Store ReplayStore
+}
+
+// NewVerifier returns a verifier with an in-memory replay store.
+func NewVerifier(skew int) (*Verifier, error) {
+	if skew < 0 {
+		return nil, ErrInvalidSkew
+	}
+
+	return &Verifier{
+		Skew:  skew,
+		Store: NewMemoryReplayStore(),
+	}, nil
+}
+
+// Verify checks the code against the token at the time of the token clock
+// and returns the matched step. The code length selects the slots that are
+// checked. A matched hotp code advances the counter of the token.
+func (v *Verifier) Verify(t *Token, code string) (uint64, error) {
+	if v.Skew < 0 {
+		return 0, ErrInvalidSkew
+	}
+
+	if len(code) == 0 {
+		return 0, ErrInvalidCode
+	}
+
+	configs, err := t.slotsOfLength(len(code))
+	if err != nil {
+		return 0, err
+	}
+	if len(configs) == 0 {
+		return 0, ErrInvalidCode
+	}
+
+	now := t.now().Unix()
+	matched := false
+	var step uint64
+	var key string
+	for _, config := range configs {
+		current := t.counter(now, config.TimeInterval)
+		first, last := current, current+uint64(v.Skew)
+		if last < current {
+			last = math.MaxUint64
+		}
+		if !t.IsHotp() {
+			if current < uint64(v.Skew) {
+				first = 0
+			} else {
+				first = current - uint64(v.Skew)
+			}
+		}
+
+		generator, err := t.generator(config)
+		if err != nil {
+			return 0, err
+		}
+
+		// All steps are checked, so the time taken does not tell which one matched
+		for counter := first; ; counter++ {
+			otp := generator.Generate(counter)
+
+			if subtle.ConstantTimeCompare([]byte(otp), []byte(code)) == 1 && !matched {
+				matched = true
+				step = counter
+				key = replayKey(config.Seed, len(code))
+			}
+
+			if counter == last {
+				break
+			}
+		}
+	}
+
+	if !matched {
+		return 0, ErrInvalidCode
+	}
+
+	if v.Store != nil {
+		ok, err := v.Store.Use(key, step)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return 0, ErrReplayedCode
+		}
+	}
+
+	if t.IsHotp() {
+		t.Counter = step + 1
+	}
+
+	return step, nil
+}
+
+// replayKey identifies a slot in replay stores without exposing its seed.
+func replayKey(seed string, otpLength int) string {
+	h := sha256.New()
+	h.Write([]byte(seed))
+	h.Write([]byte(strconv.Itoa(otpLength)))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,89 @@
+package token
+
+import (
+	"encoding/hex"
+	"otp/internal/clock"
+	"testing"
+	"time"
+)
+
+func TestVerifier(t *testing.T) {
+	otpToken := &Token{
+		FirstOtpLength: 8,
+		TimeInterval:   30000,
+		Seed:           hex.EncodeToString([]byte("12345678901234567890")),
+		Clock:          clock.Fixed(time.Unix(89, 0)),
+	}
+
+	if _, err := newVerifier(t, 0).Verify(otpToken, "94287082"); err != ErrInvalidCode {
+		t.Fatalf("code out of window : expected %v, got %v", ErrInvalidCode, err)
+	}
+
+	verifier := newVerifier(t, 1)
+
+	step, err := verifier.Verify(otpToken, "94287082")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if step != 1 {
+		t.Fatalf("expected step 1, got %d", step)
+	}
+
+	if _, err := verifier.Verify(otpToken, "94287082"); err != ErrReplayedCode {
+		t.Fatalf("expected %v, got %v", ErrReplayedCode, err)
+	}
+
+	if _, err := verifier.Verify(otpToken, "94287083"); err != ErrInvalidCode {
+		t.Fatalf("expected %v, got %v", ErrInvalidCode, err)
+	}
+
+	if _, err := verifier.Verify(otpToken, "942870"); err != ErrInvalidCode {
+		t.Fatalf("wrong length : expected %v, got %v", ErrInvalidCode, err)
+	}
+}
+
+func TestVerifierHotp(t *testing.T) {
+	otpToken := newHotpToken()
+
+	step, err := newVerifier(t, 3).Verify(otpToken, hotpVectors[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if step != 2 || otpToken.Counter != 3 {
+		t.Fatalf("unexpected step %d and counter %d", step, otpToken.Counter)
+	}
+}
+
+func TestVerifierNegativeSkew(t *testing.T) {
+	if _, err := NewVerifier(-1); err != ErrInvalidSkew {
+		t.Fatalf("expected %v, got %v", ErrInvalidSkew, err)
+	}
+
+	// A negative skew would wrap around and accept every past code
+	otpToken := &Token{
+		FirstOtpLength: 8,
+		TimeInterval:   30000,
+		Seed:           hex.EncodeToString([]byte("12345678901234567890")),
+		Clock:          clock.Fixed(time.Unix(1111111109, 0)),
+	}
+	verifier := &Verifier{Skew: -1}
+	if _, err := verifier.Verify(otpToken, "94287082"); err != ErrInvalidSkew {
+		t.Fatalf("totp : expected %v, got %v", ErrInvalidSkew, err)
+	}
+
+	if _, err := verifier.Verify(newHotpToken(), hotpVectors[0]); err != ErrInvalidSkew {
+		t.Fatalf("hotp : expected %v, got %v", ErrInvalidSkew, err)
+	}
+}
+
+func newVerifier(t *testing.T, skew int) *Verifier {
+	t.Helper()
+
+	verifier, err := NewVerifier(skew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier
+}
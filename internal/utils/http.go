@@ -11,6 +11,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
+	"otp/internal/clock"
+	"strings"
+	"time"
 )
 
 var client *http.Client
@@ -78,10 +82,12 @@ func Request(uri string, headers map[string]string, postBody []byte, result inte
 			req.Header.Set(k, v)
 		}
 	}
+	sent := time.Now()
 	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	observeDate(req.URL.Host, res, sent, time.Now())
 
 	var body []byte
 
@@ -104,3 +110,47 @@ func Request(uri string, headers map[string]string, postBody []byte, result inte
 
 	return body, err
 }
+
+// observeDate feeds the Date header of a response to the clock estimator.
+func observeDate(host string, res *http.Response, sent, received time.Time) {
+	date, err := http.ParseTime(res.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+
+	clock.Observe(host, date, time.Second, sent, received)
+}
+
+// Host returns the host part of uri, as used by the clock estimator.
+func Host(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// SyncClock sends a HEAD request to uri only to estimate the clock offset
+// of its host, and returns the estimated offset.
+func SyncClock(uri string) (time.Duration, error) {
+	req, err := http.NewRequest(http.MethodHead, uri, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "okhttp/3.10.0")
+
+	sent := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	observeDate(req.URL.Host, res, sent, time.Now())
+
+	offset, ok := clock.HostOffset(req.URL.Host)
+	if !ok {
+		return 0, errors.New("server did not send its time")
+	}
+
+	return offset, nil
+}
@@ -1,7 +1,10 @@
 package utils
 
-import "time"
+import (
+	"otp/internal/clock"
+	"time"
+)
 
-func TimestampMs() int64 {
-	return time.Now().UnixNano() / int64(time.Millisecond)
+func TimestampMs(c clock.Clock) int64 {
+	return clock.Now(c).UnixNano() / int64(time.Millisecond)
 }
@@ -0,0 +1,275 @@
+package otpauth
+
+import (
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidScheme    = errors.New("otpauth: scheme is not otpauth")
+	ErrInvalidType      = errors.New("otpauth: type is not totp or hotp")
+	ErrInvalidLabel     = errors.New("otpauth: invalid label")
+	ErrIssuerMismatch   = errors.New("otpauth: issuer of label and parameter differ")
+	ErrMissingSecret    = errors.New("otpauth: secret is missing")
+	ErrInvalidSecret    = errors.New("otpauth: secret is not base32")
+	ErrInvalidAlgorithm = errors.New("otpauth: unknown algorithm")
+	ErrInvalidDigits    = errors.New("otpauth: invalid digits")
+	ErrInvalidPeriod    = errors.New("otpauth: invalid period")
+	ErrMissingCounter   = errors.New("otpauth: counter is missing")
+	ErrInvalidCounter   = errors.New("otpauth: invalid counter")
+)
+
+const (
+	TypeTotp = "totp"
+	TypeHotp = "hotp"
+
+	MinDigits = 4
+	MaxDigits = 10
+)
+
+// Key is the validated content of an otpauth url.
+type Key struct {
+	Type        string
+	Issuer      string
+	AccountName string
+	Secret      []byte
+	Algorithm   Algorithm
+	Digits      int
+	Period      int
+	Counter     uint64
+}
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Parse parses and validates an otpauth url. Unlike NewKeyFromURL, it
+// refuses urls that authenticator apps would not accept.
+func Parse(rawURL string) (*Key, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseURL(u)
+}
+
+func parseURL(u *url.URL) (*Key, error) {
+	if !strings.EqualFold(u.Scheme, "otpauth") {
+		return nil, fmt.Errorf("%w : %s", ErrInvalidScheme, u.Scheme)
+	}
+
+	key := &Key{
+		Type:   strings.ToLower(u.Host),
+		Digits: 6,
+		Period: 30,
+	}
+
+	if key.Type != TypeTotp && key.Type != TypeHotp {
+		return nil, fmt.Errorf("%w : %s", ErrInvalidType, u.Host)
+	}
+
+	labelIssuer, accountName, err := parseLabel(u.EscapedPath())
+	if err != nil {
+		return nil, err
+	}
+	key.AccountName = accountName
+
+	q, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Issuer = q.Get("issuer")
+	if len(key.Issuer) == 0 {
+		key.Issuer = labelIssuer
+	} else if len(labelIssuer) > 0 && labelIssuer != key.Issuer {
+		return nil, fmt.Errorf("%w : %s and %s", ErrIssuerMismatch, labelIssuer, key.Issuer)
+	}
+
+	key.Secret, err = DecodeSecret(q.Get("secret"))
+	if err != nil {
+		return nil, err
+	}
+
+	if value := q.Get("algorithm"); len(value) > 0 {
+		key.Algorithm, err = ParseAlgorithm(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w : %s", ErrInvalidAlgorithm, value)
+		}
+	}
+
+	if value := q.Get("digits"); len(value) > 0 {
+		key.Digits, err = strconv.Atoi(value)
+		if err != nil || key.Digits < MinDigits || key.Digits > MaxDigits {
+			return nil, fmt.Errorf("%w : %s", ErrInvalidDigits, value)
+		}
+	}
+
+	if value := q.Get("period"); len(value) > 0 {
+		key.Period, err = strconv.Atoi(value)
+		if err != nil || key.Period <= 0 {
+			return nil, fmt.Errorf("%w : %s", ErrInvalidPeriod, value)
+		}
+	}
+
+	if key.Type == TypeHotp {
+		value := q.Get("counter")
+		if len(value) == 0 {
+			return nil, ErrMissingCounter
+		}
+
+		key.Counter, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w : %s", ErrInvalidCounter, value)
+		}
+	}
+
+	return key, nil
+}
+
+// EncodeSecret returns the base32 form of a secret, without padding.
+func EncodeSecret(secret []byte) string {
+	return base32NoPadding.EncodeToString(secret)
+}
+
+// DecodeSecret decodes a base32 secret. Lower case letters, spaces and
+// padding are accepted.
+func DecodeSecret(secret string) ([]byte, error) {
+	if len(secret) == 0 {
+		return nil, ErrMissingSecret
+	}
+
+	// Apps show secrets in groups, in lower case and with or without padding
+	secret = strings.ToUpper(strings.Replace(secret, " ", "", -1))
+	decoded, err := base32NoPadding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalidSecret, err)
+	}
+
+	if len(decoded) == 0 {
+		return nil, ErrMissingSecret
+	}
+
+	return decoded, nil
+}
+
+// Validate checks the key as Parse checks urls.
+func (k *Key) Validate() error {
+	if k.Type != TypeTotp && k.Type != TypeHotp {
+		return fmt.Errorf("%w : %s", ErrInvalidType, k.Type)
+	}
+
+	if len(k.Issuer) == 0 && len(k.AccountName) == 0 {
+		return fmt.Errorf("%w : issuer and account name are empty", ErrInvalidLabel)
+	}
+
+	if len(k.Secret) == 0 {
+		return ErrMissingSecret
+	}
+
+	if _, err := k.Algorithm.MarshalText(); err != nil {
+		return fmt.Errorf("%w : %d", ErrInvalidAlgorithm, k.Algorithm)
+	}
+
+	if k.Digits < MinDigits || k.Digits > MaxDigits {
+		return fmt.Errorf("%w : %d", ErrInvalidDigits, k.Digits)
+	}
+
+	if k.Type == TypeTotp && k.Period <= 0 {
+		return fmt.Errorf("%w : %d", ErrInvalidPeriod, k.Period)
+	}
+
+	return nil
+}
+
+// URL validates the key and returns it as an otpauth url.
+func (k *Key) URL() (string, error) {
+	if err := k.Validate(); err != nil {
+		return "", err
+	}
+
+	params := []string{
+		"secret=" + EncodeSecret(k.Secret),
+	}
+	if len(k.Issuer) > 0 {
+		params = append(params, "issuer="+queryEscape(k.Issuer))
+	}
+	params = append(params,
+		"algorithm="+k.Algorithm.String(),
+		"digits="+strconv.Itoa(k.Digits),
+	)
+	if k.Type == TypeHotp {
+		params = append(params, "counter="+strconv.FormatUint(k.Counter, 10))
+	} else {
+		params = append(params, "period="+strconv.Itoa(k.Period))
+	}
+
+	return "otpauth://" + k.Type + "/" + formatLabel(k.Issuer, k.AccountName) + "?" + strings.Join(params, "&"), nil
+}
+
+func (k *Key) String() string {
+	u, err := k.URL()
+	if err != nil {
+		return err.Error()
+	}
+	return u
+}
+
+// formatLabel returns the escaped label of an issuer and account name. A
+// colon inside either of them is escaped, so only the separator is literal.
+// Without an issuer, an account name with a colon gets an empty issuer
+// prefix, so its escaped colon is not read back as the separator.
+func formatLabel(issuer, accountName string) string {
+	if len(issuer) == 0 && !strings.Contains(accountName, ":") {
+		return labelEscape(accountName)
+	}
+	return labelEscape(issuer) + ":" + labelEscape(accountName)
+}
+
+// parseLabel splits an escaped label into the issuer and account name. The
+// separator is a literal colon or, as the key uri format allows, %3A. Only
+// the first separator splits the label, and a literal colon is preferred.
+func parseLabel(escapedPath string) (string, string, error) {
+	label := strings.TrimPrefix(escapedPath, "/")
+
+	separator := strings.Index(label, ":")
+	width := 1
+	if separator < 0 {
+		separator = strings.Index(strings.ToUpper(label), "%3A")
+		width = 3
+	}
+
+	var issuer, accountName string
+	var err error
+	if separator < 0 {
+		accountName, err = url.PathUnescape(label)
+	} else {
+		issuer, err = url.PathUnescape(label[:separator])
+		if err == nil {
+			accountName, err = url.PathUnescape(label[separator+width:])
+		}
+	}
+
+	if err != nil {
+		return "", "", fmt.Errorf("%w : %v", ErrInvalidLabel, err)
+	}
+
+	if len(issuer) == 0 && len(accountName) == 0 {
+		return "", "", fmt.Errorf("%w : label is empty", ErrInvalidLabel)
+	}
+
+	return issuer, strings.TrimSpace(accountName), nil
+}
+
+func labelEscape(s string) string {
+	return strings.Replace(url.PathEscape(s), ":", "%3A", -1)
+}
+
+// queryEscape escapes a query value with %20 for spaces, as the key uri
+// format asks.
+func queryEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
@@ -0,0 +1,153 @@
+package otpauth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	key, err := Parse("otpauth://totp/ACME%20Co:john.doe@email.com?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ&issuer=ACME%20Co&algorithm=SHA1&digits=6&period=30")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Type != TypeTotp || key.Issuer != "ACME Co" || key.AccountName != "john.doe@email.com" || key.Digits != 6 || key.Period != 30 || len(key.Secret) != 20 {
+		t.Fatalf("wrong key : %+v", key)
+	}
+
+	key, err = Parse("otpauth://hotp/Example%3Aalice?secret=jbsw%20y3dp&counter=7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Issuer != "Example" || key.AccountName != "alice" || key.Counter != 7 || string(key.Secret) != "Hello" {
+		t.Fatalf("wrong key : %+v", key)
+	}
+
+	key, err = Parse("otpauth://totp/:user%3A1?secret=JBSWY3DP")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Issuer != "" || key.AccountName != "user:1" {
+		t.Fatalf("escaped colon after the separator is not part of the account name : %+v", key)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		url string
+		err error
+	}{
+		{"https://totp/a?secret=JBSWY3DP", ErrInvalidScheme},
+		{"otpauth://motp/a?secret=JBSWY3DP", ErrInvalidType},
+		{"otpauth://totp/?secret=JBSWY3DP", ErrInvalidLabel},
+		{"otpauth://totp/A:b?secret=JBSWY3DP&issuer=B", ErrIssuerMismatch},
+		{"otpauth://totp/a", ErrMissingSecret},
+		{"otpauth://totp/a?secret=JBSWY3D1", ErrInvalidSecret},
+		{"otpauth://totp/a?secret=JBSWY3DP&algorithm=SHA3", ErrInvalidAlgorithm},
+		{"otpauth://totp/a?secret=JBSWY3DP&digits=abc", ErrInvalidDigits},
+		{"otpauth://totp/a?secret=JBSWY3DP&digits=12", ErrInvalidDigits},
+		{"otpauth://totp/a?secret=JBSWY3DP&period=0", ErrInvalidPeriod},
+		{"otpauth://hotp/a?secret=JBSWY3DP", ErrMissingCounter},
+		{"otpauth://hotp/a?secret=JBSWY3DP&counter=-1", ErrInvalidCounter},
+	}
+
+	for _, test := range tests {
+		if _, err := Parse(test.url); !errors.Is(err, test.err) {
+			t.Errorf("%s : expected %v, got %v", test.url, test.err, err)
+		}
+	}
+}
+
+func TestKeyURL(t *testing.T) {
+	tests := []Key{
+		{Type: TypeTotp, Issuer: "بانک اقتصاد نوین", AccountName: "6177236", Secret: []byte("12345678901234567890"), Digits: 6, Period: 60},
+		{Type: TypeTotp, Issuer: "Bank: Tejarat", AccountName: "user:1", Secret: []byte("secret"), Algorithm: AlgorithmSHA256, Digits: 8, Period: 30},
+		{Type: TypeTotp, AccountName: "user:1", Secret: []byte("secret"), Digits: 6, Period: 30},
+		{Type: TypeHotp, AccountName: "alice", Secret: []byte("secret"), Algorithm: AlgorithmSHA512, Digits: 6, Counter: 42},
+	}
+
+	for _, key := range tests {
+		u, err := key.URL()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(u, " ") || strings.Contains(u, "+") {
+			t.Errorf("%s : spaces are not escaped with %%20", u)
+		}
+
+		if len(key.Issuer) == 0 && strings.Contains(u, "issuer=") {
+			t.Errorf("%s : empty issuer is written", u)
+		}
+
+		parsed, err := Parse(u)
+		if err != nil {
+			t.Fatalf("%s : %v", u, err)
+		}
+
+		if parsed.Issuer != key.Issuer || parsed.AccountName != key.AccountName || string(parsed.Secret) != string(key.Secret) ||
+			parsed.Algorithm != key.Algorithm || parsed.Digits != key.Digits || parsed.Counter != key.Counter {
+			t.Errorf("%s : expected %+v, got %+v", u, key, *parsed)
+		}
+	}
+
+	if _, err := (&Key{Type: TypeTotp, AccountName: "a", Secret: []byte("s"), Digits: 3, Period: 30}).URL(); !errors.Is(err, ErrInvalidDigits) {
+		t.Fatalf("expected ErrInvalidDigits, got %v", err)
+	}
+}
+
+func TestOtpAuthLabel(t *testing.T) {
+	otpAuth, err := New("بانک سینا: رمز دوم", "JBSWY3DP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otpAuth.SetAccountName("6177:236")
+	otpAuth.SetDigit(8)
+
+	key, err := Parse(otpAuth.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Issuer != "بانک سینا: رمز دوم" || key.AccountName != "6177:236" {
+		t.Fatalf("label is not kept : %s", otpAuth.URL())
+	}
+
+	if otpAuth.Issuer() != key.Issuer || otpAuth.AccountName() != key.AccountName {
+		t.Fatalf("expected %s and %s, got %s and %s", key.Issuer, key.AccountName, otpAuth.Issuer(), otpAuth.AccountName())
+	}
+}
+
+func TestOtpAuthLabelWithoutIssuer(t *testing.T) {
+	otpAuth, err := New("", "JBSWY3DP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otpAuth.SetAccountName("a:b")
+
+	if strings.Contains(otpAuth.URL(), "issuer=") {
+		t.Fatalf("empty issuer is written : %s", otpAuth.URL())
+	}
+
+	key, err := Parse(otpAuth.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Issuer != "" || key.AccountName != "a:b" {
+		t.Fatalf("label is not kept : %s", otpAuth.URL())
+	}
+
+	if otpAuth.Issuer() != "" || otpAuth.AccountName() != "a:b" {
+		t.Fatalf("expected no issuer and a:b, got %s and %s", otpAuth.Issuer(), otpAuth.AccountName())
+	}
+
+	otpAuth.SetIssuer("Bank")
+	otpAuth.SetIssuer("")
+	if strings.Contains(otpAuth.URL(), "issuer=") || otpAuth.AccountName() != "a:b" {
+		t.Fatalf("issuer is not removed : %s", otpAuth.URL())
+	}
+}
@@ -0,0 +1,395 @@
+package otpauth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidMigration  = errors.New("otpauth: invalid migration payload")
+	ErrIncompleteBatches = errors.New("otpauth: migration batches are incomplete")
+	ErrNotMigratable     = errors.New("otpauth: key can not be moved to google authenticator")
+)
+
+// MigrationBatchSize is the number of keys in each migration url, as
+// Google Authenticator exports them.
+const MigrationBatchSize = 10
+
+// Migration is a single otpauth-migration url of a Google Authenticator
+// export. A large export is split into batches with the same BatchID.
+type Migration struct {
+	Keys       []*Key
+	Version    int
+	BatchSize  int
+	BatchIndex int
+	BatchID    int
+}
+
+// Values of the migration payload enums
+const (
+	migrationSHA1   = 1
+	migrationSHA256 = 2
+	migrationSHA512 = 3
+	migrationMD5    = 4
+
+	migrationSixDigits   = 1
+	migrationEightDigits = 2
+
+	migrationHotp = 1
+	migrationTotp = 2
+)
+
+// ParseMigration decodes an otpauth-migration://offline?data= url.
+func ParseMigration(rawURL string) (*Migration, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(u.Scheme, "otpauth-migration") || !strings.EqualFold(u.Host, "offline") {
+		return nil, fmt.Errorf("%w : not an otpauth-migration://offline url", ErrInvalidScheme)
+	}
+
+	data := u.Query().Get("data")
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w : data is missing", ErrInvalidMigration)
+	}
+
+	// Some scanners turn the + of the base64 data into a space
+	data = strings.Replace(data, " ", "+", -1)
+	payload, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		payload, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+		if err != nil {
+			return nil, fmt.Errorf("%w : %v", ErrInvalidMigration, err)
+		}
+	}
+
+	return decodeMigration(payload)
+}
+
+// URL returns the migration as an otpauth-migration url. Keys must be
+// supported by Google Authenticator, see MigrationURLs.
+func (m *Migration) URL() (string, error) {
+	payload, err := m.encode()
+	if err != nil {
+		return "", err
+	}
+
+	return "otpauth-migration://offline?data=" + url.QueryEscape(base64.StdEncoding.EncodeToString(payload)), nil
+}
+
+// MigrationURLs exports the keys in batches of batchSize keys, or
+// MigrationBatchSize if it is zero. Google Authenticator only knows 30 second
+// periods and 6 or 8 digits, other keys are refused with ErrNotMigratable.
+func MigrationURLs(keys []*Key, batchSize int) ([]string, error) {
+	if batchSize <= 0 {
+		batchSize = MigrationBatchSize
+	}
+
+	for _, key := range keys {
+		if err := checkMigratable(key); err != nil {
+			return nil, err
+		}
+	}
+
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	batchID := int(binary.BigEndian.Uint32(id) >> 1)
+
+	batches := (len(keys) + batchSize - 1) / batchSize
+	var urls []string
+	for i := 0; i < batches; i++ {
+		end := (i + 1) * batchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		m := &Migration{
+			Keys:       keys[i*batchSize : end],
+			Version:    1,
+			BatchSize:  batches,
+			BatchIndex: i,
+			BatchID:    batchID,
+		}
+
+		u, err := m.URL()
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+
+	return urls, nil
+}
+
+// ParseMigrations decodes all batches of an export and returns their keys
+// in order. It fails if a batch is missing or belongs to another export.
+func ParseMigrations(rawURLs []string) ([]*Key, error) {
+	var migrations []*Migration
+	for _, rawURL := range rawURLs {
+		m, err := ParseMigration(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, m)
+	}
+
+	if len(migrations) == 0 {
+		return nil, ErrIncompleteBatches
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].BatchIndex < migrations[j].BatchIndex
+	})
+
+	var keys []*Key
+	for i, m := range migrations {
+		if m.BatchID != migrations[0].BatchID || m.BatchIndex != i || (m.BatchSize > 0 && m.BatchSize != len(migrations)) {
+			return nil, fmt.Errorf("%w : expected batch %d of %d", ErrIncompleteBatches, i+1, m.BatchSize)
+		}
+		keys = append(keys, m.Keys...)
+	}
+
+	return keys, nil
+}
+
+func checkMigratable(key *Key) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	if key.Digits != 6 && key.Digits != 8 {
+		return fmt.Errorf("%w : %d digits", ErrNotMigratable, key.Digits)
+	}
+
+	if key.Type == TypeTotp && key.Period != 30 {
+		return fmt.Errorf("%w : %d seconds period", ErrNotMigratable, key.Period)
+	}
+
+	return nil
+}
+
+func (m *Migration) encode() ([]byte, error) {
+	var payload []byte
+	for _, key := range m.Keys {
+		if err := checkMigratable(key); err != nil {
+			return nil, err
+		}
+
+		var p []byte
+		p = appendBytesField(p, 1, key.Secret)
+		p = appendBytesField(p, 2, []byte(key.AccountName))
+		p = appendBytesField(p, 3, []byte(key.Issuer))
+
+		switch key.Algorithm {
+		case AlgorithmSHA1:
+			p = appendVarintField(p, 4, migrationSHA1)
+		case AlgorithmSHA256:
+			p = appendVarintField(p, 4, migrationSHA256)
+		case AlgorithmSHA512:
+			p = appendVarintField(p, 4, migrationSHA512)
+		case AlgorithmMD5:
+			p = appendVarintField(p, 4, migrationMD5)
+		}
+
+		if key.Digits == 8 {
+			p = appendVarintField(p, 5, migrationEightDigits)
+		} else {
+			p = appendVarintField(p, 5, migrationSixDigits)
+		}
+
+		if key.Type == TypeHotp {
+			p = appendVarintField(p, 6, migrationHotp)
+			p = appendVarintField(p, 7, key.Counter)
+		} else {
+			p = appendVarintField(p, 6, migrationTotp)
+		}
+
+		payload = appendBytesField(payload, 1, p)
+	}
+
+	payload = appendVarintField(payload, 2, uint64(m.Version))
+	payload = appendVarintField(payload, 3, uint64(m.BatchSize))
+	payload = appendVarintField(payload, 4, uint64(m.BatchIndex))
+	payload = appendVarintField(payload, 5, uint64(m.BatchID))
+
+	return payload, nil
+}
+
+func decodeMigration(payload []byte) (*Migration, error) {
+	m := &Migration{}
+	err := readFields(payload, func(field int, value uint64, data []byte) error {
+		switch field {
+		case 1:
+			key, err := decodeMigrationKey(data)
+			if err != nil {
+				return err
+			}
+			m.Keys = append(m.Keys, key)
+		case 2:
+			m.Version = int(int32(value))
+		case 3:
+			m.BatchSize = int(int32(value))
+		case 4:
+			m.BatchIndex = int(int32(value))
+		case 5:
+			m.BatchID = int(int32(value))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func decodeMigrationKey(data []byte) (*Key, error) {
+	key := &Key{
+		Type:   TypeTotp,
+		Digits: 6,
+		Period: 30,
+	}
+
+	err := readFields(data, func(field int, value uint64, data []byte) error {
+		switch field {
+		case 1:
+			key.Secret = append([]byte(nil), data...)
+		case 2:
+			key.AccountName = string(data)
+		case 3:
+			key.Issuer = string(data)
+		case 4:
+			switch value {
+			case migrationSHA256:
+				key.Algorithm = AlgorithmSHA256
+			case migrationSHA512:
+				key.Algorithm = AlgorithmSHA512
+			case migrationMD5:
+				key.Algorithm = AlgorithmMD5
+			default:
+				key.Algorithm = AlgorithmSHA1
+			}
+		case 5:
+			if value == migrationEightDigits {
+				key.Digits = 8
+			}
+		case 6:
+			if value == migrationHotp {
+				key.Type = TypeHotp
+			}
+		case 7:
+			key.Counter = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The name is the whole label in some exports
+	if len(key.Issuer) > 0 && strings.HasPrefix(key.AccountName, key.Issuer+":") {
+		key.AccountName = strings.TrimSpace(strings.TrimPrefix(key.AccountName, key.Issuer+":"))
+	}
+
+	if len(key.Secret) == 0 {
+		return nil, ErrMissingSecret
+	}
+
+	return key, nil
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	b = appendVarint(b, uint64(field)<<3)
+	return appendVarint(b, v)
+}
+
+func appendBytesField(b []byte, field int, data []byte) []byte {
+	b = appendVarint(b, uint64(field)<<3|2)
+	b = appendVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func readVarint(b []byte) (uint64, int, error) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * uint(i))
+		if b[i] < 0x80 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("%w : truncated varint", ErrInvalidMigration)
+}
+
+// readFields calls fn for every field of a protobuf message. Varint fields
+// pass their value, length delimited fields pass their data. Other fields
+// are skipped.
+func readFields(b []byte, fn func(field int, value uint64, data []byte) error) error {
+	for len(b) > 0 {
+		tag, n, err := readVarint(b)
+		if err != nil {
+			return err
+		}
+		b = b[n:]
+
+		field := int(tag >> 3)
+		switch tag & 7 {
+		case 0:
+			value, n, err := readVarint(b)
+			if err != nil {
+				return err
+			}
+			b = b[n:]
+
+			if err := fn(field, value, nil); err != nil {
+				return err
+			}
+		case 1:
+			if len(b) < 8 {
+				return fmt.Errorf("%w : truncated field %d", ErrInvalidMigration, field)
+			}
+			b = b[8:]
+		case 2:
+			length, n, err := readVarint(b)
+			if err != nil {
+				return err
+			}
+			b = b[n:]
+
+			if uint64(len(b)) < length {
+				return fmt.Errorf("%w : truncated field %d", ErrInvalidMigration, field)
+			}
+
+			if err := fn(field, 0, b[:length]); err != nil {
+				return err
+			}
+			b = b[length:]
+		case 5:
+			if len(b) < 4 {
+				return fmt.Errorf("%w : truncated field %d", ErrInvalidMigration, field)
+			}
+			b = b[4:]
+		default:
+			return fmt.Errorf("%w : wire type %d", ErrInvalidMigration, tag&7)
+		}
+	}
+
+	return nil
+}
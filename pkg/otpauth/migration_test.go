@@ -0,0 +1,77 @@
+package otpauth
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseMigration(t *testing.T) {
+	m, err := ParseMigration("otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Keys) != 1 {
+		t.Fatalf("expected a key, got %d", len(m.Keys))
+	}
+
+	key := m.Keys[0]
+	if key.Type != TypeTotp || key.Issuer != "Example" || key.AccountName != "alice@google.com" || string(key.Secret) != "Hello!\xde\xad\xbe\xef" || key.Digits != 6 || key.Period != 30 {
+		t.Fatalf("wrong key : %+v", key)
+	}
+}
+
+func TestMigrationBatches(t *testing.T) {
+	var keys []*Key
+	for i := 0; i < 25; i++ {
+		key := &Key{Type: TypeTotp, Issuer: "بانک", AccountName: fmt.Sprintf("account %d", i), Secret: []byte{byte(i), 1, 2, 3}, Digits: 6, Period: 30}
+		if i%2 == 1 {
+			key.Type = TypeHotp
+			key.Counter = uint64(i * 1000)
+			key.Digits = 8
+			key.Algorithm = AlgorithmSHA256
+		}
+		keys = append(keys, key)
+	}
+
+	urls, err := MigrationURLs(keys, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 3 {
+		t.Fatalf("expected 3 batches, got %d", len(urls))
+	}
+
+	decoded, err := ParseMigrations([]string{urls[2], urls[0], urls[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(keys) {
+		t.Fatalf("expected %d keys, got %d", len(keys), len(decoded))
+	}
+
+	for i, key := range keys {
+		d := decoded[i]
+		if d.Type != key.Type || d.Issuer != key.Issuer || d.AccountName != key.AccountName || string(d.Secret) != string(key.Secret) ||
+			d.Algorithm != key.Algorithm || d.Digits != key.Digits || d.Counter != key.Counter {
+			t.Errorf("expected %+v, got %+v", key, d)
+		}
+	}
+
+	if _, err := ParseMigrations(urls[:2]); !errors.Is(err, ErrIncompleteBatches) {
+		t.Fatalf("expected ErrIncompleteBatches, got %v", err)
+	}
+}
+
+func TestMigrationUnsupportedKeys(t *testing.T) {
+	for _, key := range []*Key{
+		{Type: TypeTotp, AccountName: "a", Secret: []byte("s"), Digits: 6, Period: 60},
+		{Type: TypeTotp, AccountName: "a", Secret: []byte("s"), Digits: 7, Period: 30},
+	} {
+		if _, err := MigrationURLs([]*Key{key}, 0); !errors.Is(err, ErrNotMigratable) {
+			t.Errorf("%+v : expected ErrNotMigratable, got %v", key, err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"fmt"
 	"hash"
 	"net/url"
 	"strconv"
@@ -22,23 +23,30 @@ type OtpAuth struct {
 //   https://github.com/google/google-authenticator/wiki/Key-Uri-Format
 //
 func New(issuer, secret string) (*OtpAuth, error) {
+	q := url.Values{
+		"secret":    []string{secret},
+		"algorithm": []string{"SHA1"},
+	}
+	if issuer != "" {
+		q.Set("issuer", issuer)
+	}
+
 	u := url.URL{
-		Scheme: "otpauth",
-		Host:   "totp",
-		Path:   issuer + ":",
-		RawQuery: url.Values{
-			"secret":    []string{secret},
-			"issuer":    []string{issuer},
-			"algorithm": []string{"SHA1"},
-		}.Encode(),
+		Scheme:   "otpauth",
+		Host:     "totp",
+		RawQuery: q.Encode(),
 	}
 
-	return &OtpAuth{
+	k := &OtpAuth{
 		url: &u,
-	}, nil
+	}
+	k.setLabel(issuer, "")
+
+	return k, nil
 }
 
 // NewKeyFromURL creates a new OtpAuth from an TOTP or HOTP url.
+// The url is not validated, use Parse or Key for that.
 //
 // The URL format is documented here:
 //   https://github.com/google/google-authenticator/wiki/Key-Uri-Format
@@ -61,6 +69,24 @@ func (k *OtpAuth) String() string {
 	return k.url.String()
 }
 
+// Key validates the url and returns its content.
+func (k *OtpAuth) Key() (*Key, error) {
+	return parseURL(k.url)
+}
+
+// label returns the issuer and account name in the url path.
+func (k *OtpAuth) label() (string, string) {
+	issuer, accountName, _ := parseLabel(k.url.EscapedPath())
+	return issuer, accountName
+}
+
+func (k *OtpAuth) setLabel(issuer, accountName string) {
+	escaped := formatLabel(issuer, accountName)
+	path, _ := url.PathUnescape(escaped)
+	k.url.Path = "/" + path
+	k.url.RawPath = "/" + escaped
+}
+
 // Type returns "hotp" or "totp".
 func (k *OtpAuth) Type() string {
 	return k.url.Host
@@ -81,48 +107,35 @@ func (k *OtpAuth) Issuer() string {
 		return issuer
 	}
 
-	p := strings.TrimPrefix(k.url.Path, "/")
-	i := strings.Index(p, ":")
-
-	if i == -1 {
-		return ""
-	}
-
-	return p[:i]
+	issuer, _ = k.label()
+	return issuer
 }
 
-// SetIssuer sets the name of the issuing organization.
+// SetIssuer sets the name of the issuing organization. An empty issuer
+// removes it from the url.
 func (k *OtpAuth) SetIssuer(issuer string) {
+	accountName := k.AccountName()
+
 	q := k.url.Query()
-	q.Set("issuer", issuer)
+	if issuer == "" {
+		q.Del("issuer")
+	} else {
+		q.Set("issuer", issuer)
+	}
 	k.url.RawQuery = q.Encode()
 
-	accountName := k.AccountName()
-	if len(issuer) > 0 {
-		issuer += ":"
-	}
-	k.url.Path = issuer + accountName
+	k.setLabel(issuer, accountName)
 }
 
 // AccountName returns the name of the user's account.
 func (k *OtpAuth) AccountName() string {
-	p := strings.TrimPrefix(k.url.Path, "/")
-	i := strings.Index(p, ":")
-
-	if i == -1 {
-		return p
-	}
-
-	return strings.TrimSpace(p[i+1:])
+	_, accountName := k.label()
+	return accountName
 }
 
 // SetAccountName sets the name of the user's account.
 func (k *OtpAuth) SetAccountName(accountName string) {
-	issuer := k.Issuer()
-	if len(issuer) > 0 {
-		issuer += ":"
-	}
-	k.url.Path = issuer + accountName
+	k.setLabel(k.Issuer(), accountName)
 }
 
 // Secret returns the opaque secret for this OtpAuth.
@@ -187,7 +200,7 @@ func (k *OtpAuth) Counter() int {
 		return counter
 	}
 
-	return 6
+	return 0
 }
 
 // SetCounter sets the hotp counter value.
@@ -216,7 +229,9 @@ func (k *OtpAuth) SetAlgorithm(algorithm Algorithm) {
 // URL returns the OTP URL as a string
 func (k *OtpAuth) URL() string {
 	if k.url.Host != "hotp" {
-		k.url.Query().Del("counter")
+		q := k.url.Query()
+		q.Del("counter")
+		k.url.RawQuery = q.Encode()
 	}
 	return k.url.String()
 }
@@ -259,3 +274,57 @@ func (a Algorithm) Hash() hash.Hash {
 	}
 	panic("unreached")
 }
+
+// HashFunc returns the constructor of the hash, as expected by crypto/hmac.
+func (a Algorithm) HashFunc() func() hash.Hash {
+	switch a {
+	case AlgorithmSHA1:
+		return sha1.New
+	case AlgorithmSHA256:
+		return sha256.New
+	case AlgorithmSHA512:
+		return sha512.New
+	case AlgorithmMD5:
+		return md5.New
+	}
+	panic("unreached")
+}
+
+// ParseAlgorithm returns the algorithm with the given name, like "SHA256".
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "SHA1":
+		return AlgorithmSHA1, nil
+	case "SHA256":
+		return AlgorithmSHA256, nil
+	case "SHA512":
+		return AlgorithmSHA512, nil
+	case "MD5":
+		return AlgorithmMD5, nil
+	}
+	return AlgorithmSHA1, fmt.Errorf("unknown algorithm %q", name)
+}
+
+// MarshalText encodes the algorithm as its name.
+func (a Algorithm) MarshalText() ([]byte, error) {
+	if a < AlgorithmSHA1 || a > AlgorithmMD5 {
+		return nil, fmt.Errorf("unknown algorithm %d", a)
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText decodes the algorithm from its name. An empty name is SHA1.
+func (a *Algorithm) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*a = AlgorithmSHA1
+		return nil
+	}
+
+	algorithm, err := ParseAlgorithm(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = algorithm
+	return nil
+}
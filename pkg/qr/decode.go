@@ -0,0 +1,188 @@
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"sort"
+
+	// Formats of screenshots and photos
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/makiuchi-d/gozxing"
+	multiqrcode "github.com/makiuchi-d/gozxing/multi/qrcode"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+var ErrNotFound = errors.New("qr: no QR-Code found in image")
+
+// DecodeOptions tune the decoder for the kind of image.
+type DecodeOptions struct {
+	// TryHarder spends more time looking for codes, for photos taken at an
+	// angle or codes that are small in the image.
+	TryHarder bool
+
+	// PureBarcode is for images that contain only a code without rotation,
+	// like the images of ToImage and Encode.
+	PureBarcode bool
+}
+
+// Result is a QR-Code found in an image. Bounds is the area between the
+// centers of its finder patterns.
+type Result struct {
+	Text   string
+	Bounds image.Rectangle
+}
+
+// autoOptions are tried in order when no options are given, from the
+// fastest to the most thorough.
+var autoOptions = []DecodeOptions{
+	{PureBarcode: true},
+	{},
+	{TryHarder: true},
+}
+
+// Decode returns the content of a QR-Code in the image. Without options,
+// it tries a pure barcode first and then searches the image harder.
+func Decode(img image.Image, options *DecodeOptions) (string, error) {
+	tries := autoOptions
+	if options != nil {
+		tries = []DecodeOptions{*options}
+	}
+
+	reader := qrcode.NewQRCodeReader()
+	var lastErr error
+	for _, o := range tries {
+		for _, bitmap := range bitmaps(img) {
+			result, err := reader.Decode(bitmap, o.hints())
+			if err == nil {
+				return result.GetText(), nil
+			}
+			lastErr = err
+		}
+	}
+
+	return "", fmt.Errorf("%w : %v", ErrNotFound, lastErr)
+}
+
+// DecodeAll returns every QR-Code in the image, from top to bottom and left
+// to right. Without options, the image is searched with TryHarder.
+func DecodeAll(img image.Image, options *DecodeOptions) ([]Result, error) {
+	o := DecodeOptions{TryHarder: true}
+	if options != nil {
+		o = *options
+		o.PureBarcode = false
+	}
+
+	reader := multiqrcode.NewQRCodeMultiReader()
+	seen := make(map[string]bool)
+	var results []Result
+	var lastErr error
+	for _, bitmap := range bitmaps(img) {
+		found, err := reader.DecodeMultiple(bitmap, o.hints())
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		for _, r := range found {
+			if seen[r.GetText()] {
+				continue
+			}
+			seen[r.GetText()] = true
+			results = append(results, Result{Text: r.GetText(), Bounds: bounds(r.GetResultPoints())})
+		}
+	}
+
+	// The multi detector misses codes that fill the whole image
+	if len(results) == 0 {
+		text, err := Decode(img, options)
+		if err != nil {
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w : %v", ErrNotFound, lastErr)
+			}
+			return nil, err
+		}
+		return []Result{{Text: text, Bounds: img.Bounds()}}, nil
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Bounds.Min, results[j].Bounds.Min
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+
+	return results, nil
+}
+
+// ReadImage decodes a PNG, JPEG or GIF image.
+func ReadImage(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// ReadImageFile decodes the PNG, JPEG or GIF image of a file.
+func ReadImageFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadImage(f)
+}
+
+func (o DecodeOptions) hints() map[gozxing.DecodeHintType]interface{} {
+	hints := make(map[gozxing.DecodeHintType]interface{})
+	if o.TryHarder {
+		hints[gozxing.DecodeHintType_TRY_HARDER] = true
+	}
+	if o.PureBarcode {
+		hints[gozxing.DecodeHintType_PURE_BARCODE] = true
+	}
+	return hints
+}
+
+// bitmaps returns the image binarized for screenshots and for photos. The
+// hybrid binarizer handles uneven lighting, the global histogram binarizer
+// handles low resolution images.
+func bitmaps(img image.Image) []*gozxing.BinaryBitmap {
+	source := gozxing.NewLuminanceSourceFromImage(img)
+
+	var result []*gozxing.BinaryBitmap
+	for _, binarizer := range []gozxing.Binarizer{
+		gozxing.NewHybridBinarizer(source),
+		gozxing.NewGlobalHistgramBinarizer(source),
+	} {
+		if bitmap, err := gozxing.NewBinaryBitmap(binarizer); err == nil {
+			result = append(result, bitmap)
+		}
+	}
+	return result
+}
+
+func bounds(points []gozxing.ResultPoint) image.Rectangle {
+	var r image.Rectangle
+	for i, p := range points {
+		x, y := int(p.GetX()), int(p.GetY())
+		if i == 0 || x < r.Min.X {
+			r.Min.X = x
+		}
+		if i == 0 || y < r.Min.Y {
+			r.Min.Y = y
+		}
+		if i == 0 || x > r.Max.X {
+			r.Max.X = x
+		}
+		if i == 0 || y > r.Max.Y {
+			r.Max.Y = y
+		}
+	}
+	return r
+}
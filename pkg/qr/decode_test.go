@@ -0,0 +1,183 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+const (
+	firstURL  = "otpauth://totp/Sina:6177236?secret=GEZDGNBVGY3TQOJQ"
+	secondURL = "otpauth://totp/Saman:1234?secret=MFRGGZDFMZTWQ2LK"
+)
+
+// page draws the codes on a white page, as a screenshot of internet banking
+// shows them.
+func page(t *testing.T, positions map[string]image.Point) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 900, 600))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	for data, position := range positions {
+		code, err := Encode(data, &Options{ModuleSize: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		codeImage := code.Image()
+		draw.Draw(img, codeImage.Bounds().Add(position), codeImage, image.Point{}, draw.Src)
+	}
+
+	return img
+}
+
+func TestDecodeAll(t *testing.T) {
+	img := page(t, map[string]image.Point{
+		secondURL: {500, 300},
+		firstURL:  {40, 60},
+	})
+
+	results, err := DecodeAll(img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 || results[0].Text != firstURL || results[1].Text != secondURL {
+		t.Fatalf("wrong results %+v", results)
+	}
+
+	if !results[1].Bounds.In(image.Rect(500, 300, 900, 600)) {
+		t.Fatalf("wrong bounds %v", results[1].Bounds)
+	}
+
+	text, err := Decode(img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text != firstURL && text != secondURL {
+		t.Fatalf("wrong text %s", text)
+	}
+}
+
+func TestDecodeFormats(t *testing.T) {
+	img := page(t, map[string]image.Point{firstURL: {100, 100}})
+
+	var jpegImage, gifImage bytes.Buffer
+	if err := jpeg.Encode(&jpegImage, img, &jpeg.Options{Quality: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.Encode(&gifImage, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range []*bytes.Buffer{&jpegImage, &gifImage} {
+		decoded, err := ReadImage(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		text, err := FromImage(decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if text != firstURL {
+			t.Fatalf("expected %s, got %s", firstURL, text)
+		}
+	}
+}
+
+func TestDecodePure(t *testing.T) {
+	img, err := ToImage(firstURL, 200, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := Decode(img, &DecodeOptions{PureBarcode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text != firstURL {
+		t.Fatalf("expected %s, got %s", firstURL, text)
+	}
+
+	blank := image.NewGray(image.Rect(0, 0, 100, 100))
+	if _, err := DecodeAll(blank, nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// photo draws a small code in a large noisy image, rotated by the angle in
+// radians and tilted like a photo of a screen taken from below. The noise is
+// seeded so the image is the same on every run.
+func photo(t *testing.T, angle, tilt float64) image.Image {
+	code, err := Encode(firstURL, &Options{ModuleSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	codeImage := code.Image()
+	codeSize := float64(codeImage.Bounds().Dx())
+
+	const size = 1600
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	sin, cos := math.Sin(angle), math.Cos(angle)
+	noise := rand.New(rand.NewSource(3))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			// Rows lower in the photo are nearer and larger
+			scale := 1 + tilt*float64(y-size/2)/size
+			dx, dy := float64(x-size/2)*scale, float64(y-size/2)*scale
+			u, v := cos*dx+sin*dy+codeSize/2, -sin*dx+cos*dy+codeSize/2
+
+			l := 255
+			if u >= 0 && v >= 0 && u < codeSize && v < codeSize {
+				l = int(color.GrayModel.Convert(codeImage.At(int(u), int(v))).(color.Gray).Y)
+			}
+			l += noise.Intn(121) - 60
+			if l < 0 {
+				l = 0
+			} else if l > 255 {
+				l = 255
+			}
+			img.SetGray(x, y, color.Gray{Y: uint8(l)})
+		}
+	}
+
+	return img
+}
+
+func TestDecodePhoto(t *testing.T) {
+	img := photo(t, 0.35, 0.3)
+
+	if _, err := Decode(img, &DecodeOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the photo to need TryHarder, got %v", err)
+	}
+
+	for _, options := range []*DecodeOptions{{TryHarder: true}, nil} {
+		text, err := Decode(img, options)
+		if err != nil {
+			t.Fatalf("%+v : %v", options, err)
+		}
+
+		if text != firstURL {
+			t.Fatalf("expected %s, got %s", firstURL, text)
+		}
+	}
+
+	results, err := DecodeAll(img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || results[0].Text != firstURL {
+		t.Fatalf("wrong results %+v", results)
+	}
+}
@@ -0,0 +1,255 @@
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode/decoder"
+	"github.com/makiuchi-d/gozxing/qrcode/encoder"
+)
+
+var ErrInvalidOptions = errors.New("qr: invalid options")
+
+// Level is the error correction level of a QR-Code. Higher levels survive
+// more damage, like a logo over the code, but need larger codes.
+type Level int
+
+const (
+	LevelL Level = iota // recovers 7% of the code
+	LevelM              // recovers 15% of the code
+	LevelQ              // recovers 25% of the code
+	LevelH              // recovers 30% of the code
+)
+
+const (
+	// DefaultMargin is the quiet zone that the QR-Code specification asks for
+	DefaultMargin = 4
+
+	// DefaultModuleSize makes codes of enrollment urls about 400 pixels wide
+	DefaultModuleSize = 8
+)
+
+// Options of the encoder. The zero value encodes with LevelL, the default
+// margin and module size, and black on white.
+type Options struct {
+	Level Level
+
+	// Margin is the quiet zone around the code in modules. Zero is
+	// DefaultMargin, a negative margin leaves no quiet zone.
+	Margin int
+
+	// ModuleSize is the width of a module in pixels. Zero is
+	// DefaultModuleSize.
+	ModuleSize int
+
+	// CharacterSet is the encoding of the data, like "UTF-8" or
+	// "ISO-8859-1". If it is empty, data that is not ASCII is encoded as
+	// UTF-8.
+	CharacterSet string
+
+	Foreground color.Color
+	Background color.Color
+}
+
+// Code is an encoded QR-Code.
+type Code struct {
+	modules    *encoder.ByteMatrix
+	margin     int
+	moduleSize int
+	foreground color.Color
+	background color.Color
+}
+
+// Encode encodes data as a QR-Code. Options may be nil.
+func Encode(data string, options *Options) (*Code, error) {
+	if options == nil {
+		options = &Options{}
+	}
+
+	level, err := options.Level.errorCorrectionLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w : data is empty", ErrInvalidOptions)
+	}
+
+	qrCode, err := encoder.Encoder_encode(data, level, options.hints(data))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Code{
+		modules:    qrCode.GetMatrix(),
+		margin:     options.Margin,
+		moduleSize: options.ModuleSize,
+		foreground: options.Foreground,
+		background: options.Background,
+	}
+
+	if c.margin == 0 {
+		c.margin = DefaultMargin
+	} else if c.margin < 0 {
+		c.margin = 0
+	}
+	if c.moduleSize <= 0 {
+		c.moduleSize = DefaultModuleSize
+	}
+	if c.foreground == nil {
+		c.foreground = color.Black
+	}
+	if c.background == nil {
+		c.background = color.White
+	}
+
+	return c, nil
+}
+
+// Size returns the width of the code in modules, with the margin.
+func (c *Code) Size() int {
+	return c.modules.GetWidth() + 2*c.margin
+}
+
+// Black reports whether the module at x, y is dark. Coordinates include
+// the margin.
+func (c *Code) Black(x, y int) bool {
+	x, y = x-c.margin, y-c.margin
+	if x < 0 || y < 0 || x >= c.modules.GetWidth() || y >= c.modules.GetHeight() {
+		return false
+	}
+	return c.modules.Get(x, y) == 1
+}
+
+// Image returns the code as an image with the module size of the options.
+func (c *Code) Image() image.Image {
+	size := c.Size() * c.moduleSize
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{c.background, c.foreground})
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if c.Black(x/c.moduleSize, y/c.moduleSize) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	return img
+}
+
+// WritePNG writes the code as a PNG image.
+func (c *Code) WritePNG(w io.Writer) error {
+	return png.Encode(w, c.Image())
+}
+
+// WriteSVG writes the code as an SVG image. The view box is in modules, so
+// the image scales without blurring, and the width and height follow the
+// module size.
+func (c *Code) WriteSVG(w io.Writer) error {
+	size := c.Size()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size*c.moduleSize, size*c.moduleSize, size, size)
+	b.WriteString("\n")
+
+	if background, ok := svgColor(c.background); ok {
+		fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, size, size, background)
+		b.WriteString("\n")
+	}
+
+	// Runs of dark modules of each row are drawn as one rectangle
+	foreground, _ := svgColor(c.foreground)
+	fmt.Fprintf(&b, `<path fill="%s" d="`, foreground)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; {
+			if !c.Black(x, y) {
+				x++
+				continue
+			}
+
+			run := 1
+			for c.Black(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", x, y, run, run)
+			x += run
+		}
+	}
+	b.WriteString("\"/>\n</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// svgColor returns a color as #rrggbb, and false if it is transparent.
+func svgColor(c color.Color) (string, bool) {
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return "none", false
+	}
+
+	// Colors are alpha premultiplied
+	r, g, b = r*0xffff/a, g*0xffff/a, b*0xffff/a
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8), true
+}
+
+func (l Level) errorCorrectionLevel() (decoder.ErrorCorrectionLevel, error) {
+	switch l {
+	case LevelL:
+		return decoder.ErrorCorrectionLevel_L, nil
+	case LevelM:
+		return decoder.ErrorCorrectionLevel_M, nil
+	case LevelQ:
+		return decoder.ErrorCorrectionLevel_Q, nil
+	case LevelH:
+		return decoder.ErrorCorrectionLevel_H, nil
+	}
+	return 0, fmt.Errorf("%w : error correction level %d", ErrInvalidOptions, l)
+}
+
+// ParseLevel returns the error correction level with the given name, one
+// of L, M, Q and H.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "L":
+		return LevelL, nil
+	case "M":
+		return LevelM, nil
+	case "Q":
+		return LevelQ, nil
+	case "H":
+		return LevelH, nil
+	}
+	return 0, fmt.Errorf("%w : error correction level %s", ErrInvalidOptions, name)
+}
+
+func (o *Options) hints(data string) map[gozxing.EncodeHintType]interface{} {
+	hints := make(map[gozxing.EncodeHintType]interface{})
+
+	characterSet := o.CharacterSet
+	if len(characterSet) == 0 && !isASCII(data) {
+		characterSet = "UTF-8"
+	}
+	if len(characterSet) > 0 {
+		hints[gozxing.EncodeHintType_CHARACTER_SET] = characterSet
+	}
+
+	return hints
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// An otpauth url with a Persian issuer that is not escaped
+const persianURL = "otpauth://totp/بانک سینا:6177236?secret=GEZDGNBVGY3TQOJQ&issuer=بانک سینا"
+
+func TestEncode(t *testing.T) {
+	for _, level := range []Level{LevelL, LevelM, LevelQ, LevelH} {
+		code, err := Encode(persianURL, &Options{Level: level, ModuleSize: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var b bytes.Buffer
+		if err := code.WritePNG(&b); err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := png.Decode(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if img.Bounds().Dx() != code.Size()*3 {
+			t.Fatalf("expected %d pixels, got %d", code.Size()*3, img.Bounds().Dx())
+		}
+
+		data, err := FromImage(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if data != persianURL {
+			t.Fatalf("level %d : expected %s, got %s", level, persianURL, data)
+		}
+	}
+
+	if _, err := Encode(persianURL, &Options{Level: 4}); !errors.Is(err, ErrInvalidOptions) {
+		t.Fatalf("expected ErrInvalidOptions, got %v", err)
+	}
+}
+
+func TestMargin(t *testing.T) {
+	withMargin, err := Encode("otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withoutMargin, err := Encode("otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ", &Options{Margin: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if withMargin.Size() != withoutMargin.Size()+2*DefaultMargin {
+		t.Fatalf("expected a margin of %d, got sizes %d and %d", DefaultMargin, withMargin.Size(), withoutMargin.Size())
+	}
+
+	// The finder pattern starts at the corner without a margin
+	if withMargin.Black(0, 0) || !withoutMargin.Black(0, 0) || !withMargin.Black(DefaultMargin, DefaultMargin) {
+		t.Fatal("wrong margin")
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	code, err := Encode("otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ", &Options{
+		ModuleSize: 10,
+		Foreground: color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff},
+		Background: color.Transparent,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := code.WriteSVG(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	svg := b.String()
+	size := code.Size()
+	if !strings.Contains(svg, `viewBox="0 0 `) || !strings.Contains(svg, `fill="#123456"`) || strings.Contains(svg, "<rect") {
+		t.Fatalf("wrong svg\n%s", svg)
+	}
+
+	if !strings.Contains(svg, "M4 4h7v1h-7z") {
+		t.Fatalf("missing top of finder pattern in %d modules\n%s", size, svg)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if level, err := ParseLevel("q"); err != nil || level != LevelQ {
+		t.Fatalf("expected LevelQ, got %d %v", level, err)
+	}
+
+	if _, err := ParseLevel("X"); !errors.Is(err, ErrInvalidOptions) {
+		t.Fatalf("expected ErrInvalidOptions, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package qr
+
+import (
+	"image"
+	"otp/pkg/otpauth"
+)
+
+// MigrationImages returns the Google Authenticator transfer QR-Codes of the
+// keys, one image of the specified size for each batch.
+func MigrationImages(keys []*otpauth.Key, size int) ([]image.Image, error) {
+	urls, err := otpauth.MigrationURLs(keys, otpauth.MigrationBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []image.Image
+	for _, u := range urls {
+		img, err := ToImage(u, size, size)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+
+	return images, nil
+}
+
+// KeysFromMigrationImages decodes all transfer QR-Codes of an export.
+func KeysFromMigrationImages(images []image.Image) ([]*otpauth.Key, error) {
+	var urls []string
+	for _, img := range images {
+		u, err := FromImage(img)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+
+	return otpauth.ParseMigrations(urls)
+}
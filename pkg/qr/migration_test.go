@@ -0,0 +1,55 @@
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"otp/pkg/otpauth"
+	"testing"
+)
+
+func TestMigrationImages(t *testing.T) {
+	var keys []*otpauth.Key
+	for i := 0; i < 2*otpauth.MigrationBatchSize+3; i++ {
+		key := &otpauth.Key{Type: otpauth.TypeTotp, Issuer: "بانک سینا", AccountName: fmt.Sprintf("account %d", i), Secret: []byte{byte(i), 1, 2, 3, 4, 5, 6, 7, 8, 9}, Digits: 6, Period: 30}
+		if i%3 == 1 {
+			key.Type = otpauth.TypeHotp
+			key.Counter = uint64(i * 1000)
+			key.Digits = 8
+			key.Algorithm = otpauth.AlgorithmSHA256
+		}
+		keys = append(keys, key)
+	}
+
+	images, err := MigrationImages(keys, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 3 {
+		t.Fatalf("expected 3 images, got %d", len(images))
+	}
+
+	decoded, err := KeysFromMigrationImages([]image.Image{images[1], images[2], images[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(keys) {
+		t.Fatalf("expected %d keys, got %d", len(keys), len(decoded))
+	}
+	for i, key := range keys {
+		d := decoded[i]
+		if d.Type != key.Type || d.Issuer != key.Issuer || d.AccountName != key.AccountName || string(d.Secret) != string(key.Secret) ||
+			d.Algorithm != key.Algorithm || d.Digits != key.Digits || d.Counter != key.Counter {
+			t.Errorf("expected %+v, got %+v", key, d)
+		}
+	}
+
+	if _, err := KeysFromMigrationImages(images[:2]); !errors.Is(err, otpauth.ErrIncompleteBatches) {
+		t.Fatalf("expected ErrIncompleteBatches, got %v", err)
+	}
+
+	if _, err := KeysFromMigrationImages([]image.Image{image.NewGray(image.Rect(0, 0, 100, 100))}); err == nil {
+		t.Fatal("image without a QR-Code is decoded")
+	}
+}
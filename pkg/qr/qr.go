@@ -6,27 +6,17 @@ import (
 	"image"
 )
 
-// FromImage decodes an QR-Code image
+// FromImage decodes an QR-Code image. It is Decode without options, see
+// DecodeAll for images with more than one code.
 func FromImage(qrImage image.Image) (string, error) {
-	reader := qrcode.NewQRCodeReader()
-	bitmap, err := gozxing.NewBinaryBitmapFromImage(qrImage)
-	if err != nil {
-		return "", err
-	}
-
-	res, err := reader.DecodeWithoutHints(bitmap)
-
-	if err != nil {
-		return "", err
-	}
-
-	return res.GetText(), nil
+	return Decode(qrImage, nil)
 }
 
 // ToImage returns an QR-Code image of the specified width and height,
 // suitable for use by many clients like Google-Authenticator
-// to enroll a user's TOTP/HOTP key.
+// to enroll a user's TOTP/HOTP key. Use Encode to choose the error
+// correction level, margin or module size.
 func ToImage(data string, width int, height int) (image.Image, error) {
 	writer := qrcode.NewQRCodeWriter()
-	return writer.EncodeWithoutHint(data, gozxing.BarcodeFormat_QR_CODE, width, height)
+	return writer.Encode(data, gozxing.BarcodeFormat_QR_CODE, width, height, (&Options{}).hints(data))
 }
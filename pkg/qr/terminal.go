@@ -0,0 +1,174 @@
+package qr
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TerminalMode is how a code is drawn with text.
+type TerminalMode int
+
+const (
+	// TerminalHalfBlock draws two rows of modules in each line with the
+	// Unicode half blocks ▀, ▄ and █, so modules are about square. The
+	// blocks are drawn in the text color of the terminal.
+	TerminalHalfBlock TerminalMode = iota
+
+	// TerminalANSI draws half blocks with black and white ANSI colors, so
+	// the code does not depend on the colors of the terminal.
+	TerminalANSI
+
+	// TerminalASCII draws every module as two characters, for terminals and
+	// fonts without block elements.
+	TerminalASCII
+)
+
+// TerminalOptions of WriteTerminal. The zero value draws half blocks for a
+// terminal with light text on a dark background and keeps the margin of the
+// code.
+type TerminalOptions struct {
+	Mode TerminalMode
+
+	// Invert draws the dark modules with the text color, for terminals
+	// with dark text on a light background. Scanners need dark modules on
+	// a light quiet zone, so the light modules are drawn by default. ANSI
+	// mode sets the colors itself and ignores it.
+	Invert bool
+
+	// QuietZone is the margin around the code in modules. Zero keeps the
+	// margin of the code, a negative quiet zone leaves none.
+	QuietZone int
+}
+
+const (
+	ansiBlack   = "\x1b[30m"
+	ansiWhite   = "\x1b[97m"
+	ansiOnBlack = "\x1b[40m"
+	ansiOnWhite = "\x1b[107m"
+	ansiDefault = "\x1b[49m"
+	ansiReset   = "\x1b[0m"
+)
+
+// WriteTerminal writes the code as text that can be scanned from a terminal,
+// also over SSH and inside terminal multiplexers. Options may be nil.
+func (c *Code) WriteTerminal(w io.Writer, options *TerminalOptions) error {
+	if options == nil {
+		options = &TerminalOptions{}
+	}
+
+	quietZone := options.QuietZone
+	if quietZone == 0 {
+		quietZone = c.margin
+	} else if quietZone < 0 {
+		quietZone = 0
+	}
+
+	// Coordinates of Black include the margin of the code
+	first := c.margin - quietZone
+	last := c.Size() - c.margin + quietZone
+
+	// drawn reports whether the module is drawn with the text color
+	drawn := func(x, y int) bool {
+		if y >= last {
+			return false
+		}
+		return c.Black(x, y) == options.Invert
+	}
+
+	var b strings.Builder
+	switch options.Mode {
+	case TerminalHalfBlock:
+		for y := first; y < last; y += 2 {
+			for x := first; x < last; x++ {
+				b.WriteString(halfBlock(drawn(x, y), drawn(x, y+1)))
+			}
+			b.WriteString("\n")
+		}
+
+	case TerminalANSI:
+		for y := first; y < last; y += 2 {
+			for x := first; x < last; x++ {
+				// The upper module is the text color and the lower
+				// module is the background color of ▀
+				b.WriteString(ansiForeground(c.Black(x, y)))
+				if y+1 < last {
+					b.WriteString(ansiBackground(c.Black(x, y+1)))
+				} else {
+					b.WriteString(ansiDefault)
+				}
+				b.WriteString("▀")
+			}
+			b.WriteString(ansiReset + "\n")
+		}
+
+	case TerminalASCII:
+		for y := first; y < last; y++ {
+			for x := first; x < last; x++ {
+				if drawn(x, y) {
+					b.WriteString("##")
+				} else {
+					b.WriteString("  ")
+				}
+			}
+			b.WriteString("\n")
+		}
+
+	default:
+		return fmt.Errorf("%w : terminal mode %d", ErrInvalidOptions, options.Mode)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Terminal returns the code as text, see WriteTerminal.
+func (c *Code) Terminal(options *TerminalOptions) (string, error) {
+	var b strings.Builder
+	if err := c.WriteTerminal(&b, options); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// ParseTerminalMode returns the terminal mode with the given name, one of
+// halfblock, ansi and ascii.
+func ParseTerminalMode(name string) (TerminalMode, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "halfblock", "half-block", "utf8":
+		return TerminalHalfBlock, nil
+	case "ansi":
+		return TerminalANSI, nil
+	case "ascii":
+		return TerminalASCII, nil
+	}
+	return 0, fmt.Errorf("%w : terminal mode %s", ErrInvalidOptions, name)
+}
+
+func halfBlock(upper, lower bool) string {
+	switch {
+	case upper && lower:
+		return "█"
+	case upper:
+		return "▀"
+	case lower:
+		return "▄"
+	}
+	return " "
+}
+
+// ansiForeground and ansiBackground return the color of a dark or light
+// module.
+func ansiForeground(dark bool) string {
+	if dark {
+		return ansiBlack
+	}
+	return ansiWhite
+}
+
+func ansiBackground(dark bool) string {
+	if dark {
+		return ansiOnBlack
+	}
+	return ansiOnWhite
+}
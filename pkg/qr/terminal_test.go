@@ -0,0 +1,124 @@
+package qr
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// scan turns text of WriteTerminal back into an image, with a pixel for
+// every module. Drawn modules are light unless the text was inverted.
+func scan(t *testing.T, text string, mode TerminalMode, invert bool) image.Image {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+
+	var rows [][]bool
+	for _, line := range lines {
+		switch mode {
+		case TerminalHalfBlock:
+			var upper, lower []bool
+			for _, r := range line {
+				upper = append(upper, r == '█' || r == '▀')
+				lower = append(lower, r == '█' || r == '▄')
+			}
+			rows = append(rows, upper, lower)
+		case TerminalASCII:
+			var row []bool
+			for i := 0; i < len(line); i += 2 {
+				row = append(row, line[i] == '#')
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	img := image.NewGray(image.Rect(0, 0, len(rows[0])*4, len(rows)*4))
+	for y := range rows {
+		if len(rows[y]) != len(rows[0]) {
+			t.Fatalf("line %d has %d modules instead of %d", y, len(rows[y]), len(rows[0]))
+		}
+		for x, drawn := range rows[y] {
+			if drawn != invert {
+				for i := 0; i < 16; i++ {
+					img.SetGray(x*4+i%4, y*4+i/4, color.Gray{Y: 0xff})
+				}
+			}
+		}
+	}
+	return img
+}
+
+func TestWriteTerminal(t *testing.T) {
+	code, err := Encode(persianURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		mode   TerminalMode
+		invert bool
+	}{
+		{TerminalHalfBlock, false},
+		{TerminalHalfBlock, true},
+		{TerminalASCII, false},
+		{TerminalASCII, true},
+	} {
+		text, err := code.Terminal(&TerminalOptions{Mode: test.mode, Invert: test.invert, QuietZone: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := FromImage(scan(t, text, test.mode, test.invert))
+		if err != nil {
+			t.Fatalf("mode %d invert %v : %v\n%s", test.mode, test.invert, err, text)
+		}
+
+		if data != persianURL {
+			t.Fatalf("expected %s, got %s", persianURL, data)
+		}
+	}
+}
+
+func TestTerminalSize(t *testing.T) {
+	code, err := Encode("otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	symbol := code.Size() - 2*DefaultMargin
+	for _, test := range []struct {
+		options *TerminalOptions
+		width   int
+		lines   int
+	}{
+		{nil, code.Size(), (code.Size() + 1) / 2},
+		{&TerminalOptions{QuietZone: -1}, symbol, (symbol + 1) / 2},
+		{&TerminalOptions{Mode: TerminalASCII, QuietZone: 1}, 2 * (symbol + 2), symbol + 2},
+		{&TerminalOptions{Mode: TerminalANSI, QuietZone: 1}, symbol + 2, (symbol + 3) / 2},
+	} {
+		text, err := code.Terminal(test.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+		width := strings.Count(lines[0], "▀")
+		if test.options == nil || test.options.Mode == TerminalHalfBlock {
+			width = len([]rune(lines[0]))
+		} else if test.options.Mode == TerminalASCII {
+			width = len(lines[0])
+		}
+
+		if len(lines) != test.lines || width != test.width {
+			t.Errorf("%+v : expected %dx%d, got %dx%d", test.options, test.width, test.lines, width, len(lines))
+		}
+	}
+
+	if _, err := code.Terminal(&TerminalOptions{Mode: 3}); !errors.Is(err, ErrInvalidOptions) {
+		t.Fatalf("expected ErrInvalidOptions, got %v", err)
+	}
+
+	if mode, err := ParseTerminalMode("ASCII"); err != nil || mode != TerminalASCII {
+		t.Fatalf("expected TerminalASCII, got %d %v", mode, err)
+	}
+}